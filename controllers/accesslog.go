@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zeoagency/carbon/services/input"
+	"github.com/zeoagency/carbon/services/sitemap"
+)
+
+// AccessLog finds alternatives for 404 URLs in a local access log,
+// and writes the result as an excel file. The result is a HTML or PDF report if the output ends with ".html" or ".pdf".
+//
+// Usage:
+//
+//	accesslog -path /var/log/nginx/access.log -host zeo.org -country tr -language tr [-scheme http] [-out result.xlsx] [-sitemap sitemap.xml]
+func AccessLog(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("accesslog", flag.ContinueOnError)
+	fs.SetOutput(out)
+	path := fs.String("path", "", "access log path, it can be gzipped")
+	host := fs.String("host", "", "host of the URLs")
+	scheme := fs.String("scheme", "https", "scheme of the URLs, \"http\" or \"https\", it is used if the log line doesn't tell it")
+	fCountry := fs.String("country", "", "country code")
+	fLanguage := fs.String("language", "", "language code")
+	output := fs.String("out", "result.xlsx", "excel, HTML or PDF file path to write")
+	fVerify := fs.String("verify", "", "\"drop\" or \"demote\" alternatives that are not live")
+	fPrecheck := fs.Bool("precheck", false, "skip URLs that are not broken anymore")
+	fLayout := fs.String("layout", "", "workbook layout name, it is read from LAYOUTS_PATH")
+	sitemapPath := fs.String("sitemap", "", "local sitemap path, it is used instead of SERP providers if it is set")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *path == "" || *host == "" || *fCountry == "" || *fLanguage == "" {
+		return errors.New("path, host, country and language must be set.")
+	}
+	if err := checkVerify(*fVerify); err != nil {
+		return err
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hits, err := input.ReadAccessLog(file, *host, *scheme)
+	if err != nil {
+		return err
+	}
+	if len(hits) == 0 {
+		return errors.New("There is no 404 URL for the host in the access log.")
+	}
+
+	values, counts := []string{}, make(map[string]int)
+	for _, h := range hits {
+		values = append(values, h.URL)
+		counts[h.URL] = h.Count
+	}
+
+	rType, country, language, provider, verify, precheck = "url", *fCountry, *fLanguage, "serp", *fVerify, *fPrecheck
+	layoutName = *fLayout
+	_, err = checkAndSetLayout()
+	if err != nil {
+		return err
+	}
+	if *sitemapPath != "" {
+		urls, err := sitemap.LoadFile(*sitemapPath)
+		if err != nil {
+			return err
+		}
+		provider, sitemapIndex = "sitemap", sitemap.NewIndex(urls)
+	}
+
+	var f *bytes.Buffer
+	switch strings.ToLower(filepath.Ext(*output)) {
+	case ".html", ".pdf":
+		format = strings.ToLower(filepath.Ext(*output))[1:]
+		f, _, err = getReportResultForURLs(context.Background(), values, counts)
+	default:
+		format = "excel"
+		f, _, err = getExcelResultForURLs(context.Background(), values, counts)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(*output, f.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%d URLs are written to %s\n", len(values), *output)
+	return nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/zeoagency/carbon/services/account"
+	"github.com/zeoagency/carbon/services/excel"
+	"github.com/zeoagency/carbon/services/sheet"
+)
+
+var (
+	accountStore     account.Store
+	accountStoreErr  error
+	accountStoreOnce sync.Once
+)
+
+// getAccountStore returns the account store that is defined at the env.
+// The store is created only once for the lambda instance.
+func getAccountStore() (account.Store, error) {
+	accountStoreOnce.Do(func() {
+		accountStore, accountStoreErr = account.NewStoreFromEnv()
+	})
+	return accountStore, accountStoreErr
+}
+
+// Accounts works like router for the admin commands.
+//
+// Usage:
+//
+//	accounts list
+//	accounts add -name bora@zeo.org -limit 500 [-password ...] [-layout ...] [-sharing ...]
+//	accounts disable -name bora@zeo.org
+//	accounts enable -name bora@zeo.org
+//	accounts rotate -name bora@zeo.org
+//	accounts layout -name bora@zeo.org -layout client-a
+//	accounts sharing -name bora@zeo.org -sharing private,bora@zeo.org:writer
+//
+// If the password is not given, a random one is generated and printed.
+func Accounts(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("Command must be \"list\", \"add\", \"disable\", \"enable\", \"rotate\", \"layout\" or \"sharing\".")
+	}
+
+	s, err := account.NewStoreFromEnv()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(out)
+	name := fs.String("name", "", "account name")
+	password := fs.String("password", "", "account password, generated if it is empty")
+	limit := fs.Int("limit", 100, "value limit, \"-1\" means there is no limit")
+	fLayout := fs.String("layout", "", "workbook layout name, the default one is used if it is empty")
+	fSharing := fs.String("sharing", "", "sharing spec of Google Sheets, anyone can read if it is empty")
+	err = fs.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		return listAccounts(s, out)
+	}
+
+	ws, ok := s.(account.WritableStore)
+	if !ok {
+		return account.ErrReadOnly
+	}
+	if *name == "" {
+		return errors.New("name is not set.")
+	}
+	if _, err := excel.LoadLayout(*fLayout); err != nil {
+		return fmt.Errorf("Layout \"%s\" is not valid: %s", *fLayout, err)
+	}
+	if _, err := sheet.ParseSharing(*fSharing); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		p, err := passwordOrGenerate(*password, out)
+		if err != nil {
+			return err
+		}
+		return ws.Add(account.Account{
+			Name:     *name,
+			Password: account.HashPassword(p),
+			Limit:    *limit,
+			Layout:   *fLayout,
+			Sharing:  *fSharing,
+		})
+	case "disable":
+		return ws.SetDisabled(*name, true)
+	case "enable":
+		return ws.SetDisabled(*name, false)
+	case "rotate":
+		p, err := passwordOrGenerate(*password, out)
+		if err != nil {
+			return err
+		}
+		return ws.SetPassword(*name, account.HashPassword(p))
+	case "layout":
+		return ws.SetLayout(*name, *fLayout)
+	case "sharing":
+		return ws.SetSharing(*name, *fSharing)
+	default:
+		return fmt.Errorf("Command \"%s\" is not supported.", args[0])
+	}
+}
+
+// listAccounts prints all accounts as a table.
+func listAccounts(s account.Store, out io.Writer) error {
+	accounts, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLIMIT\tSTATUS\tLAYOUT\tSHARING")
+	for _, a := range accounts {
+		status := "active"
+		if a.Disabled {
+			status = "disabled"
+		}
+		accountLayout := a.Layout
+		if accountLayout == "" {
+			accountLayout = "default"
+		}
+		accountSharing := a.Sharing
+		if accountSharing == "" {
+			accountSharing = sheet.LinkAnyone
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", a.Name, a.Limit, status, accountLayout, accountSharing)
+	}
+	return w.Flush()
+}
+
+// passwordOrGenerate returns the given password, or generates and prints a new one.
+func passwordOrGenerate(password string, out io.Writer) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	p, err := account.GeneratePassword()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(out, "Password: %s\n", p)
+	return p, nil
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/zeoagency/carbon/services/ratelimit"
+)
+
+var (
+	limiter     *ratelimit.Limiter
+	limiterErr  error
+	limiterOnce sync.Once
+)
+
+// getLimiter returns the rate limiter that is defined at the env.
+// The limiter is created only once for the lambda instance.
+func getLimiter() (*ratelimit.Limiter, error) {
+	limiterOnce.Do(func() {
+		limiter, limiterErr = ratelimit.NewLimiterFromEnv()
+	})
+	return limiter, limiterErr
+}
+
+// checkAuthAndRateLimit limits the source IP, authenticates the internal account and limits the account.
+// The IP is limited before the password is checked, so passwords can't be guessed without a limit.
+// Login attempts have their own limit for the IP, other requests use the anonymous limit.
+// It returns seconds to wait before retrying if a limit is exceeded.
+func checkAuthAndRateLimit(request events.APIGatewayProxyRequest) (bool, int, int, int, error) {
+	ip := request.RequestContext.Identity.SourceIP
+	_, isLogin := request.QueryStringParameters["accountName"]
+	retryAfter, status, err := checkRateLimit(func(l *ratelimit.Limiter) (bool, time.Duration, error) {
+		if isLogin {
+			return l.AllowLogin(ip)
+		}
+		return l.AllowIP(ip)
+	})
+	if err != nil {
+		return false, 0, retryAfter, status, err
+	}
+
+	// Check internal.
+	// iLimit
+	//    "0" = non-login user.
+	//    "-1" = limitless user.
+	//    "..." = limit is defined.
+	isInternal, iLimit, status, err := checkAndAuthInternal(request)
+	if err != nil || !isInternal {
+		return isInternal, iLimit, 0, status, err
+	}
+
+	// Accounts are limited by their names.
+	retryAfter, status, err = checkRateLimit(func(l *ratelimit.Limiter) (bool, time.Duration, error) {
+		return l.AllowAccount(request.QueryStringParameters["accountName"])
+	})
+	return isInternal, iLimit, retryAfter, status, err
+}
+
+// checkRateLimit takes a token by using the limiter.
+// It returns seconds to wait before retrying if the limit is exceeded.
+func checkRateLimit(allow func(l *ratelimit.Limiter) (bool, time.Duration, error)) (int, int, error) {
+	l, err := getLimiter()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.New("We have some issues with the rate limiter. Please try later.")
+	}
+
+	ok, wait, err := allow(l)
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.New("We have some issues with the rate limiter. Please try later.")
+	}
+	if !ok {
+		return retryAfterSeconds(wait), http.StatusTooManyRequests, errors.New("Too many requests. Please try later.")
+	}
+
+	return 0, http.StatusOK, nil
+}
+
+// retryAfterSeconds rounds the wait duration up to seconds.
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
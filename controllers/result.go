@@ -2,98 +2,194 @@ package controllers
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/mail"
+	neturl "net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 
 	"github.com/zeoagency/carbon/models"
 	"github.com/zeoagency/carbon/services"
+	"github.com/zeoagency/carbon/services/account"
+	"github.com/zeoagency/carbon/services/check"
 	"github.com/zeoagency/carbon/services/excel"
+	"github.com/zeoagency/carbon/services/export"
+	"github.com/zeoagency/carbon/services/input"
+	"github.com/zeoagency/carbon/services/notify"
+	"github.com/zeoagency/carbon/services/report"
 	"github.com/zeoagency/carbon/services/sheet"
+	"github.com/zeoagency/carbon/services/sitemap"
 )
 
-// requestBody keeps request body.
-type requestBody struct {
-	Values []struct {
-		Value string `json:"value"`
-	} `json:"values"`
-}
+var rType, format, country, language, provider, verify string
 
-type internal struct {
-	Accounts []struct {
-		Name     string `json:"name"`
-		Password string `json:"password"`
-		Limit    int    `json:"limit"` // "-1" means there is no limit.
-	} `json:"accounts"`
-}
+// precheck is set to check input URLs are broken before looking up alternatives.
+var precheck bool
+
+// sitemapIndex is used when the provider is "sitemap".
+var sitemapIndex *sitemap.Index
+
+var (
+	sheetWriter     sheet.Writer
+	sheetWriterErr  error
+	sheetWriterOnce sync.Once
+)
+
+// sheetOptions tells where the result is written for "sheet" format.
+var sheetOptions sheet.Options
+
+// sharing is the sharing spec of the request, sharingPolicy is the account's.
+// The policy is a ceiling for the request, the default sharing is the policy of anonymous requests.
+var sharing string
+var sharingPolicy string
+
+// destination is where file results are uploaded, files are returned in the response if it is not set.
+var destination string
 
-var rType, format, country, language string
+// exporters keeps the exporters of the destinations, they are created from the env file when they are needed.
+var exporters = map[string]export.Exporter{}
+
+// callbackURL and notifyEmails are notified when the result is ready or failed.
+var callbackURL string
+var notifyEmails []string
+
+// counts keeps the counts of the result, they are sent with the notifications.
+var counts map[string]int
+
+// layoutName is the workbook layout of the request or the account, layout is the loaded one.
+var layoutName string
+var layout *excel.Layout
 
 // Result works like router.
 //
 // You need to send type and format in the request.
 // You will get a response that is related with request.
-func Result(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+// The context is the context of the lambda, if its deadline is reached while looking up,
+// the partial result is returned, see lookupContext.
+func Result(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Admins can see the health of the SERP API keys.
+	if isSerpKeysStatus(request) {
+		return serpKeysStatus(request), nil
+	}
+
 	// Set params, returns an error if the param is not set.
 	status, err := checkAndSetParams(request)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: status,
-			Body:       `{ "error": "` + err.Error() + `" }`,
-		}, nil
+		return errorResponse(status, err, 0), nil
+	}
+
+	// Authenticate and check the rate limits.
+	isInternal, iLimit, retryAfter, status, err := checkAuthAndRateLimit(request)
+	if err != nil {
+		return errorResponse(status, err, retryAfter), nil
 	}
 
-	// Check internal.
-	// iLimit
-	//    "0" = non-login user.
-	//    "-1" = limitless user.
-	//    "..." = limit is defined.
-	isInternal, iLimit, status, err := checkAndAuthInternal(request)
+	// Check the options that only internal accounts can use.
+	status, err = checkInternalOptions(request, isInternal)
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: status,
-			Body:       `{ "error": "` + err.Error() + `" }`,
-		}, nil
+		return errorResponse(status, err, 0), nil
+	}
+
+	// Callbacks must not reach the private network.
+	if callbackURL != "" {
+		err = notify.CheckCallbackURL(ctx, callbackURL)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, err, 0), nil
+		}
 	}
 
 	// Process the request.
-	f, sheetURL, status, err := getResult(request, isInternal, iLimit)
+	f, sheetURL, status, err := getResult(ctx, request, isInternal, iLimit)
+
+	// If the destination is set, upload the file and return its link.
+	fileURL := ""
+	if err == nil && f != nil && destination != "" {
+		fileURL, status, err = exportFile(ctx, f)
+	}
+
+	// Notify the callback URL and the emails, retries of failed ones stop before the deadline of the lambda.
+	notifyResult(ctx, sheetURL+fileURL, err)
+
 	if err != nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: status,
-			Body:       `{ "error": "` + err.Error() + `" }`,
-		}, nil
+		return errorResponse(status, err, 0), nil
 	}
 
-	if f != nil {
+	if f != nil && destination != "" {
+		return jsonResponse(status, map[string]string{"destination": destination, "fileURL": fileURL}), nil
+	} else if f != nil {
 		// If the return value is a file, serve it.
 		return serveFile(f), nil
 	} else {
 		// If the return value is not a file, then it must be a sheetURL.
-		return events.APIGatewayProxyResponse{
-			StatusCode: status,
-			Body:       `{ "sheetURL": "` + sheetURL + `" }`,
-		}, nil
+		return jsonResponse(status, map[string]string{"sheetURL": sheetURL}), nil
+	}
+}
+
+// jsonResponse returns the response with the marshalled body, URLs can have quotes.
+func jsonResponse(status int, body map[string]string) events.APIGatewayProxyResponse {
+	b, _ := json.Marshal(body)
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Body:       string(b),
+	}
+}
+
+// errorResponse returns the response of the error, Retry-After is set if the seconds are positive.
+// The body is marshalled, messages can have quotes or the values of the request.
+func errorResponse(status int, err error, retryAfter int) events.APIGatewayProxyResponse {
+	res := jsonResponse(status, map[string]string{"error": err.Error()})
+	if retryAfter > 0 {
+		res.Headers = map[string]string{
+			"Retry-After": strconv.Itoa(retryAfter),
+		}
 	}
+	return res
 }
 
 // getResult returns the result by evaluating the option inputs.
-func getResult(request events.APIGatewayProxyRequest, isInternal bool, iLimit int) (*bytes.Buffer, string, int, error) {
-	// Unmarshal the json request.
-	var rBody requestBody
-	err := json.Unmarshal([]byte(request.Body), &rBody)
+func getResult(ctx context.Context, request events.APIGatewayProxyRequest, isInternal bool, iLimit int) (*bytes.Buffer, string, int, error) {
+	body, err := getBody(request)
 	if err != nil {
-		return nil, "", http.StatusBadRequest, errors.New("Error occur while unmarshalling body-json value. Check your request.")
+		return nil, "", http.StatusBadRequest, err
 	}
 
-	status, err := checkLimit(len(rBody.Values), isInternal, iLimit)
+	// Parse the values by evaluating the content type.
+	values, hits, status, err := getValues(request, body)
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	// Set the provider.
+	status, err = checkAndSetProvider(ctx, request, body)
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	// Set the layout.
+	status, err = checkAndSetLayout()
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	// Set the sharing of Google Sheets.
+	sheetOptions.Sharing, status, err = checkAndParseSharing()
+	if err != nil {
+		return nil, "", status, err
+	}
+
+	status, err = checkLimit(len(values), isInternal, iLimit)
 	if err != nil {
 		return nil, "", status, err
 	}
@@ -101,24 +197,30 @@ func getResult(request events.APIGatewayProxyRequest, isInternal bool, iLimit in
 	if rType == "url" {
 		switch format {
 		case "excel":
-			f, status, err := getExcelResultForURLs(rBody)
+			f, status, err := getExcelResultForURLs(ctx, values, hits)
+			return f, "", status, err
+		case "html", "pdf":
+			f, status, err := getReportResultForURLs(ctx, values, hits)
 			return f, "", status, err
 		case "sheet":
-			sheetURL, status, err := getSheetResultForURLs(rBody)
+			sheetURL, status, err := getSheetResultForURLs(ctx, values, hits)
 			return nil, sheetURL, status, err
 		default:
-			return nil, "", http.StatusBadRequest, errors.New("Format must be \"excel\" or \"sheet\"")
+			return nil, "", http.StatusBadRequest, errors.New("Format must be \"excel\", \"sheet\", \"html\" or \"pdf\".")
 		}
 	} else if isInternal && rType == "keyword" {
 		switch format {
 		case "excel":
-			f, status, err := getExcelResultForKeywords(rBody)
+			f, status, err := getExcelResultForKeywords(ctx, values)
+			return f, "", status, err
+		case "html", "pdf":
+			f, status, err := getReportResultForKeywords(ctx, values)
 			return f, "", status, err
 		case "sheet":
-			sheetURL, status, err := getSheetResultForKeywords(rBody)
+			sheetURL, status, err := getSheetResultForKeywords(ctx, values)
 			return nil, sheetURL, status, err
 		default:
-			return nil, "", http.StatusBadRequest, errors.New("Format must be \"excel\" or \"sheet\"")
+			return nil, "", http.StatusBadRequest, errors.New("Format must be \"excel\", \"sheet\", \"html\" or \"pdf\".")
 		}
 	} else {
 		errText := "Type must be \"url\"."
@@ -129,22 +231,199 @@ func getResult(request events.APIGatewayProxyRequest, isInternal bool, iLimit in
 	}
 }
 
-// getExcelResultForURLs returns excel file for the given request.
-func getExcelResultForURLs(rBody requestBody) (*bytes.Buffer, int, error) {
+// getBody returns the request body, it is decoded if it is base64 encoded.
+func getBody(request events.APIGatewayProxyRequest) ([]byte, error) {
+	if !request.IsBase64Encoded {
+		return []byte(request.Body), nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(request.Body)
+	if err != nil {
+		return nil, errors.New("Error occur while decoding the body. Check your request.")
+	}
+	return b, nil
+}
+
+// checkAndSetLayout loads the workbook layout.
+// The default layout is used if the request or the account doesn't have one.
+func checkAndSetLayout() (int, error) {
+	l, err := excel.LoadLayout(layoutName)
+	if err == excel.ErrLayoutNotFound {
+		return http.StatusBadRequest, fmt.Errorf("Layout \"%s\" is not found.", layoutName)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("We have some issue with the layout. Please try later.")
+	}
+
+	layout = l
+	return http.StatusOK, nil
+}
+
+// checkAndSetProvider sets the provider to find alternatives.
+//
+// The provider is "serp" (default) or "sitemap".
+// For "sitemap", the sitemap is uploaded as the "sitemap" form file,
+// or its URL is set with the "sitemap" param by internal accounts, see internalOptions.
+func checkAndSetProvider(ctx context.Context, request events.APIGatewayProxyRequest, body []byte) (int, error) {
+	provider = request.QueryStringParameters["provider"]
+	sitemapIndex = nil
+
+	switch provider {
+	case "", "serp":
+		provider = "serp"
+		return http.StatusOK, nil
+	case "sitemap":
+		if rType != "url" {
+			return http.StatusBadRequest, errors.New("Sitemap provider is only supported for \"url\" type.")
+		}
+	default:
+		return http.StatusBadRequest, errors.New("Provider must be \"serp\" or \"sitemap\".")
+	}
+
+	var urls []string
+	data, ok, err := input.FormFile(getHeader(request, "Content-Type"), body, input.SitemapField)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if ok {
+		urls, err = sitemap.Load(bytes.NewReader(data), func(location string) (io.ReadCloser, error) {
+			return nil, errors.New("Sitemap indexes must be given as an URL.")
+		})
+	} else if u := request.QueryStringParameters["sitemap"]; u != "" {
+		urls, err = sitemap.LoadURL(ctx, u)
+	} else {
+		return http.StatusBadRequest, errors.New("sitemap is not set.")
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	sitemapIndex = sitemap.NewIndex(urls)
+	return http.StatusOK, nil
+}
+
+// getValues returns the values in the request body.
+// The body can be JSON, plain text, CSV, XLSX or multipart form.
+//
+// If the source is "accesslog", the body is an access log,
+// and the values are 404 URLs of the host with their hit counts.
+func getValues(request events.APIGatewayProxyRequest, body []byte) ([]string, map[string]int, int, error) {
+	if request.QueryStringParameters["source"] == "accesslog" {
+		if rType != "url" {
+			return nil, nil, http.StatusBadRequest, errors.New("Access logs are only supported for \"url\" type.")
+		}
+		result, err := input.ParseAccessLog(getHeader(request, "Content-Type"), body, request.QueryStringParameters["host"], request.QueryStringParameters["scheme"])
+		if err != nil {
+			return nil, nil, http.StatusBadRequest, err
+		}
+		values, hits := []string{}, make(map[string]int)
+		for _, h := range result {
+			values = append(values, h.URL)
+			hits[h.URL] = h.Count
+		}
+		return values, hits, http.StatusOK, nil
+	}
+
+	values, err := input.Parse(getHeader(request, "Content-Type"), body, input.Options{
+		Sheet:  request.QueryStringParameters["sheet"],
+		Column: request.QueryStringParameters["column"],
+		Header: request.QueryStringParameters["header"] == "true",
+	})
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, err
+	}
+
+	return values, nil, http.StatusOK, nil
+}
+
+// getURLSet looks up alternatives of the values, and returns the URLSet.
+func getURLSet(ctx context.Context, values []string, hits map[string]int) (*models.URLSet, int, error) {
 	// Create a new Set with inputs.
 	urlSet := models.NewURLSet()
-	for _, v := range rBody.Values {
-		urlSet.Add(v.Value)
+	urlSet.Meta.Country, urlSet.Meta.Language = country, language
+	urlSet.Add(values...)
+	for url, count := range hits {
+		urlSet.AddHits(url, count)
+	}
+
+	// Lookups stop before the deadline, the rest is marked as timed out.
+	lookupCtx, cancel := lookupContext(ctx)
+	defer cancel()
+
+	// Skip URLs that are not broken.
+	if precheck {
+		services.PreCheckURLs(lookupCtx, urlSet, check.NewChecker())
 	}
 
 	// Get the result
-	status, err := services.GetResultByUsingURLs(urlSet, country, language)
+	var status int
+	var err error
+	if len(urlSet.URLs) == 0 {
+		status = http.StatusOK // Nothing to look up.
+	} else if provider == "sitemap" {
+		status, err = services.GetResultByUsingSitemap(lookupCtx, urlSet, sitemapIndex)
+	} else {
+		status, err = services.GetResultByUsingURLs(lookupCtx, urlSet, country, language)
+	}
+	if err != nil {
+		return nil, status, err
+	}
+
+	// Verify the alternatives are live.
+	if verify != "" {
+		services.VerifyAlternatives(lookupCtx, urlSet, check.NewChecker(), verify == "drop")
+	}
+
+	// Collapse suggestions that point to other broken URLs in the batch.
+	services.ResolveRedirectChains(urlSet)
+
+	counts = map[string]int{
+		"total":   len(urlSet.Order),
+		"success": len(urlSet.Successes),
+		"fail":    len(urlSet.Fails),
+		"skipped": len(urlSet.Skips),
+	}
+	if urlSet.Meta.TimedOut {
+		counts["timedOut"] = 0
+		for _, fail := range urlSet.Fails {
+			if fail.Reason == services.ReasonTimedOut {
+				counts["timedOut"]++
+			}
+		}
+	}
+
+	return urlSet, http.StatusOK, nil
+}
+
+// lookupContext returns the context of the lookups, it is done before the deadline of the request,
+// so there is still time to create and write the partial result.
+// The reserved time is RESULT_RESERVE_SECONDS (default 15), it is limited to the half of the remaining time.
+func lookupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	seconds, err := strconv.Atoi(os.Getenv("RESULT_RESERVE_SECONDS"))
+	if err != nil || seconds < 0 {
+		seconds = 15
+	}
+	reserve := time.Duration(seconds) * time.Second
+	if half := time.Until(deadline) / 2; half > 0 && reserve > half {
+		reserve = half
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// getExcelResultForURLs returns excel file for the given request.
+func getExcelResultForURLs(ctx context.Context, values []string, hits map[string]int) (*bytes.Buffer, int, error) {
+	urlSet, status, err := getURLSet(ctx, values, hits)
 	if err != nil {
 		return nil, status, err
 	}
 
 	// Convert the result to excel.
-	f, err := excel.ConvertURLResultToExcel(urlSet)
+	f, err := excel.ConvertURLResultToExcelWithLayout(ctx, urlSet, layout)
 	if err != nil {
 		return nil, http.StatusInternalServerError, errors.New("We have some issue while creating the excel output. Please try later.")
 	}
@@ -152,35 +431,69 @@ func getExcelResultForURLs(rBody requestBody) (*bytes.Buffer, int, error) {
 	return f, http.StatusCreated, nil
 }
 
-// getSheetResultForURLs returns sheet url for the given request.
-func getSheetResultForURLs(rBody requestBody) (string, int, error) {
-	f, status, err := getExcelResultForURLs(rBody)
+// getReportResultForURLs returns HTML or PDF report for the given request.
+func getReportResultForURLs(ctx context.Context, values []string, hits map[string]int) (*bytes.Buffer, int, error) {
+	urlSet, status, err := getURLSet(ctx, values, hits)
 	if err != nil {
-		return "", status, err
+		return nil, status, err
 	}
-	sheetURL, err := sheet.ImportFileToGoogleSheets(f)
+
+	return convertReport(excel.URLReport(urlSet, layout))
+}
+
+// getSheetResultForURLs returns sheet url for the given request.
+func getSheetResultForURLs(ctx context.Context, values []string, hits map[string]int) (string, int, error) {
+	urlSet, status, err := getURLSet(ctx, values, hits)
 	if err != nil {
-		return "", http.StatusInternalServerError, errors.New("We have some issue with Google Sheets. Please try later.")
+		return "", status, err
 	}
-	return sheetURL, http.StatusCreated, nil
+
+	return writeSheet(ctx, excel.URLReport(urlSet, layout))
 }
 
-// getExcelResultForKeywords returns excel file for the given request.
-func getExcelResultForKeywords(rBody requestBody) (*bytes.Buffer, int, error) {
+// getKeywordSet looks up results of the values, and returns the KeywordSet.
+func getKeywordSet(ctx context.Context, values []string) (*models.KeywordSet, int, error) {
 	// Create a new Set with inputs.
 	keywordSet := models.NewKeywordSet()
-	for _, v := range rBody.Values {
-		keywordSet.Add(v.Value)
-	}
+	keywordSet.Meta.Country, keywordSet.Meta.Language = country, language
+	keywordSet.Add(values...)
+
+	// Lookups stop before the deadline, the rest is marked as timed out.
+	lookupCtx, cancel := lookupContext(ctx)
+	defer cancel()
 
 	// Get the result
-	status, err := services.GetResultByUsingKeywords(keywordSet, country, language)
+	status, err := services.GetResultByUsingKeywords(lookupCtx, keywordSet, country, language)
+	if err != nil {
+		return nil, status, err
+	}
+
+	counts = map[string]int{
+		"total":   len(keywordSet.Order),
+		"success": len(keywordSet.Successes),
+		"fail":    len(keywordSet.Fails),
+	}
+	if keywordSet.Meta.TimedOut {
+		counts["timedOut"] = 0
+		for _, fail := range keywordSet.Fails {
+			if fail.Reason == services.ReasonTimedOut {
+				counts["timedOut"]++
+			}
+		}
+	}
+
+	return keywordSet, http.StatusOK, nil
+}
+
+// getExcelResultForKeywords returns excel file for the given request.
+func getExcelResultForKeywords(ctx context.Context, values []string) (*bytes.Buffer, int, error) {
+	keywordSet, status, err := getKeywordSet(ctx, values)
 	if err != nil {
 		return nil, status, err
 	}
 
 	// Convert the result to excel.
-	f, err := excel.ConvertKeywordResultToExcel(keywordSet)
+	f, err := excel.ConvertKeywordResultToExcelWithLayout(ctx, keywordSet, layout)
 	if err != nil {
 		return nil, http.StatusInternalServerError, errors.New("We have some issue while creating the excel output. Please try later.")
 	}
@@ -188,19 +501,88 @@ func getExcelResultForKeywords(rBody requestBody) (*bytes.Buffer, int, error) {
 	return f, http.StatusCreated, nil
 }
 
+// getReportResultForKeywords returns HTML or PDF report for the given request.
+func getReportResultForKeywords(ctx context.Context, values []string) (*bytes.Buffer, int, error) {
+	keywordSet, status, err := getKeywordSet(ctx, values)
+	if err != nil {
+		return nil, status, err
+	}
+
+	return convertReport(excel.KeywordReport(keywordSet, layout))
+}
+
+// convertReport converts the report to the requested format, "html" or "pdf".
+func convertReport(r excel.Report) (*bytes.Buffer, int, error) {
+	var f *bytes.Buffer
+	var err error
+	if format == "pdf" {
+		f, err = report.ConvertToPDF(r)
+	} else {
+		f, err = report.ConvertToHTML(r)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("We have some issue while creating the report. Please try later.")
+	}
+
+	return f, http.StatusCreated, nil
+}
+
 // getSheetResultForKeywords returns sheet url for the given request.
-func getSheetResultForKeywords(rBody requestBody) (string, int, error) {
-	f, status, err := getExcelResultForKeywords(rBody)
+func getSheetResultForKeywords(ctx context.Context, values []string) (string, int, error) {
+	keywordSet, status, err := getKeywordSet(ctx, values)
 	if err != nil {
 		return "", status, err
 	}
-	sheetURL, err := sheet.ImportFileToGoogleSheets(f)
+
+	return writeSheet(ctx, excel.KeywordReport(keywordSet, layout))
+}
+
+// getSheetWriter returns the writer of Google Sheets that is defined at the env.
+// The writer is created only once for the lambda instance.
+func getSheetWriter() (sheet.Writer, error) {
+	sheetWriterOnce.Do(func() {
+		w, err := sheet.NewWriterFromEnv()
+		if err == nil {
+			sheetWriter = w
+		}
+		sheetWriterErr = err
+	})
+	return sheetWriter, sheetWriterErr
+}
+
+// writeSheet writes the report to Google Sheets, and returns its URL.
+func writeSheet(ctx context.Context, r excel.Report) (string, int, error) {
+	w, err := getSheetWriter()
+	if err != nil {
+		status, err := sheetError(err)
+		return "", status, err
+	}
+
+	sheetURL, err := w.Write(ctx, r, sheetOptions)
+	if err == sheet.ErrSpreadsheetNotFound || errors.Is(err, sheet.ErrColumns) {
+		return "", http.StatusBadRequest, err
+	}
 	if err != nil {
-		return "", http.StatusInternalServerError, errors.New("We have some issue with Google Sheets. Please try later.")
+		status, err := sheetError(err)
+		return "", status, err
 	}
 	return sheetURL, http.StatusCreated, nil
 }
 
+// sheetError logs the error of Google Sheets, and returns the status and the message for the user.
+func sheetError(err error) (int, error) {
+	log.Printf("Error: %v", err)
+	switch {
+	case errors.Is(err, sheet.ErrQuota):
+		return http.StatusServiceUnavailable, errors.New("Google Sheets quota is exceeded. Please try later.")
+	case errors.Is(err, sheet.ErrPermission):
+		return http.StatusForbidden, errors.New("We don't have the permission for the spreadsheet or the folder on Google Drive.")
+	case errors.Is(err, sheet.ErrAuth):
+		return http.StatusInternalServerError, errors.New("We couldn't authorize with Google Sheets. Please try later.")
+	}
+	return http.StatusInternalServerError, errors.New("We have some issue with Google Sheets. Please try later.")
+}
+
 // checkAndAuthInternal checks if the request includes internal info or not.
 // If there is internal keys, validates them.
 func checkAndAuthInternal(request events.APIGatewayProxyRequest) (bool, int, int, error) {
@@ -218,27 +600,66 @@ func checkAndAuthInternal(request events.APIGatewayProxyRequest) (bool, int, int
 		return false, 0, http.StatusUnauthorized, errors.New("Password is empty.")
 	}
 
-	internalJSON := os.Getenv("INTERNAL_ACCOUNTS_JSON")
-	if internalJSON == "" {
-		return false, 0, http.StatusUnauthorized, errors.New("Authorization is not valid.")
+	s, err := getAccountStore()
+	if err != nil {
+		return false, 0, http.StatusInternalServerError, errors.New("We have some issues with excepting internal account logins. Please try later.")
 	}
 
-	i := internal{}
-	err := json.Unmarshal([]byte(internalJSON), &i)
+	a, ok, err := account.Authenticate(s, accountName, accountPassword)
 	if err != nil {
 		return false, 0, http.StatusInternalServerError, errors.New("We have some issues with excepting internal account logins. Please try later.")
 	}
+	if !ok {
+		return false, 0, http.StatusUnauthorized, errors.New("Authorization is not valid.")
+	}
 
-	h := sha256.New()
-	h.Write([]byte(accountPassword))
-	passwordHash := fmt.Sprintf("%x", h.Sum(nil))
-	for _, x := range i.Accounts {
-		if accountName == x.Name && passwordHash == x.Password {
-			return true, x.Limit, http.StatusOK, nil
-		}
+	// The layout of the request has priority over the account's, the sharing of the account is a ceiling.
+	if layoutName == "" {
+		layoutName = a.Layout
 	}
+	sharingPolicy = a.Sharing
 
-	return false, 0, http.StatusUnauthorized, errors.New("Authorization is not valid.")
+	return true, a.Limit, http.StatusOK, nil
+}
+
+// checkAndParseSharing returns the sharing of the request, or the account's if it is not set.
+// The request can only narrow the sharing of the account, see sheet.Sharing.Within.
+func checkAndParseSharing() (sheet.Sharing, int, error) {
+	policy, err := sheet.ParseSharing(sharingPolicy)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return sheet.Sharing{}, http.StatusInternalServerError, errors.New("Sharing of the account is not valid.")
+	}
+	if sharing == "" {
+		return policy, http.StatusOK, nil
+	}
+
+	s, err := sheet.ParseSharing(sharing)
+	if err != nil {
+		return sheet.Sharing{}, http.StatusBadRequest, err
+	}
+	err = s.Within(policy)
+	if err != nil {
+		return sheet.Sharing{}, http.StatusForbidden, err
+	}
+	return s, http.StatusOK, nil
+}
+
+// internalOptions are the params that only internal accounts can use.
+// They make the service fetch, write or send to places that are out of the request.
+var internalOptions = []string{"sitemap", "precheck", "spreadsheetId", "append", "folderId", "destination", "callbackURL", "notifyEmail"}
+
+// checkInternalOptions rejects the internal options for anonymous requests.
+func checkInternalOptions(request events.APIGatewayProxyRequest, isInternal bool) (int, error) {
+	if isInternal {
+		return http.StatusOK, nil
+	}
+	for _, o := range internalOptions {
+		if request.QueryStringParameters[o] != "" {
+			return http.StatusForbidden, fmt.Errorf("%s is only available for internal accounts.", o)
+		}
+	}
+	return http.StatusOK, nil
 }
 
 // checkAndGetParams checks the params are set or not.
@@ -269,9 +690,74 @@ func checkAndSetParams(request events.APIGatewayProxyRequest) (int, error) {
 		return http.StatusBadRequest, err
 	}
 
+	// Optional, input URLs are checked if it is set.
+	precheck = request.QueryStringParameters["precheck"] == "true"
+
+	// Optional, the default layout is used if it is not set.
+	layoutName = request.QueryStringParameters["layout"]
+
+	// Optional, they are used for "sheet" format.
+	sheetOptions = sheet.Options{
+		SpreadsheetID: request.QueryStringParameters["spreadsheetId"],
+		Append:        request.QueryStringParameters["append"],
+		Name:          request.QueryStringParameters["fileName"],
+		FolderID:      request.QueryStringParameters["folderId"],
+	}
+	if sheetOptions.Append != "" && sheetOptions.Append != sheet.AppendTabs && sheetOptions.Append != sheet.AppendRows {
+		return http.StatusBadRequest, errors.New("Append must be \"tabs\" or \"rows\".")
+	}
+	sharing = request.QueryStringParameters["sharing"]
+	sharingPolicy = ""
+
+	// Optional, file results are uploaded to the destination if it is set.
+	destination = request.QueryStringParameters["destination"]
+	if destination != "" && !export.IsDestination(destination) {
+		return http.StatusBadRequest, errors.New("Destination must be \"s3\", \"onedrive\", \"dropbox\" or \"webhook\".")
+	}
+	if destination != "" && format == "sheet" {
+		return http.StatusBadRequest, errors.New("Destination can not be used with \"sheet\" format.")
+	}
+
+	// Optional, they are notified when the result is ready or failed.
+	callbackURL = request.QueryStringParameters["callbackURL"]
+	if callbackURL != "" {
+		u, err := neturl.Parse(callbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return http.StatusBadRequest, errors.New("Callback URL must be an HTTP or HTTPS URL.")
+		}
+	}
+	notifyEmails = nil
+	if v := request.QueryStringParameters["notifyEmail"]; v != "" {
+		for _, email := range strings.Split(v, ",") {
+			address, err := mail.ParseAddress(strings.TrimSpace(email))
+			if err != nil {
+				return http.StatusBadRequest, fmt.Errorf("Email \"%s\" is not valid.", email)
+			}
+			notifyEmails = append(notifyEmails, address.Address)
+		}
+		if _, err := notify.NewSMTPNotifierFromEnv(notifyEmails); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	counts = nil
+
+	// Optional, alternatives are verified if it is set.
+	verify = request.QueryStringParameters["verify"]
+	if err := checkVerify(verify); err != nil {
+		return http.StatusBadRequest, err
+	}
+
 	return http.StatusOK, nil
 }
 
+// checkVerify returns an error if the verify option is not empty, "drop" or "demote".
+func checkVerify(v string) error {
+	if v != "" && v != "drop" && v != "demote" {
+		return errors.New("Verify must be \"drop\" or \"demote\".")
+	}
+	return nil
+}
+
 // getParam returns params if it exists.
 func getParam(request events.APIGatewayProxyRequest, param string) (string, error) {
 	if v, ok := request.QueryStringParameters[param]; ok {
@@ -281,6 +767,16 @@ func getParam(request events.APIGatewayProxyRequest, param string) (string, erro
 	}
 }
 
+// getHeader returns the header value, the name is case-insensitive.
+func getHeader(request events.APIGatewayProxyRequest, name string) string {
+	for k, v := range request.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
 // checkLimit checks limit for the user.
 func checkLimit(bodyLen int, isInternal bool, iLimit int) (int, error) {
 	if bodyLen == 0 {
@@ -307,16 +803,111 @@ func checkLimit(bodyLen int, isInternal bool, iLimit int) (int, error) {
 	return http.StatusOK, nil
 }
 
-// serveFile create a response to serve the given file.
+// fileTypes keeps the file name and the content type of the formats.
+var fileTypes = map[string][2]string{
+	"excel": {"result.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	"html":  {"result.html", "text/html; charset=utf-8"},
+	"pdf":   {"result.pdf", "application/pdf"},
+}
+
+// fileTypeOf returns the file name and the content type of the format.
+func fileTypeOf(format string) [2]string {
+	fileType, ok := fileTypes[format]
+	if !ok {
+		fileType = fileTypes["excel"]
+	}
+	return fileType
+}
+
+// serveFile create a response to serve the given file, by using the format.
 func serveFile(f *bytes.Buffer) events.APIGatewayProxyResponse {
+	fileType := fileTypeOf(format)
+
+	// The file is encoded to a builder that has the exact size, so it is copied only once.
+	body := strings.Builder{}
+	body.Grow(base64.StdEncoding.EncodedLen(f.Len()))
+	encoder := base64.NewEncoder(base64.StdEncoding, &body)
+	encoder.Write(f.Bytes())
+	encoder.Close()
+
 	return events.APIGatewayProxyResponse{
 		StatusCode: http.StatusCreated,
 		Headers: map[string]string{
-			"Content-Disposition": `attachment; filename="result.xlsx"`,
+			"Content-Disposition": `attachment; filename="` + fileType[0] + `"`,
 			"Content-Length":      strconv.Itoa(f.Len()),
-			"Content-Type":        "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			"Content-Type":        fileType[1],
 		},
-		Body:            base64.StdEncoding.EncodeToString(f.Bytes()),
+		Body:            body.String(),
 		IsBase64Encoded: true,
 	}
 }
+
+// getExporter returns the exporter of the destination, it is created only once for the lambda instance.
+func getExporter(destination string) (export.Exporter, error) {
+	if e, ok := exporters[destination]; ok {
+		return e, nil
+	}
+	e, err := export.NewExporterFromEnv(destination)
+	if err != nil {
+		return nil, err
+	}
+	exporters[destination] = e
+	return e, nil
+}
+
+// exportFile uploads the file to the destination, and returns its link.
+func exportFile(ctx context.Context, f *bytes.Buffer) (string, int, error) {
+	e, err := getExporter(destination)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", http.StatusInternalServerError, fmt.Errorf("Destination \"%s\" is not configured.", destination)
+	}
+
+	fileType := fileTypeOf(format)
+	fileURL, err := e.Export(ctx, export.File{Name: fileType[0], ContentType: fileType[1], Body: f.Bytes()})
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return "", http.StatusBadGateway, fmt.Errorf("We have some issue with the destination \"%s\". Please try later.", destination)
+	}
+	return fileURL, http.StatusCreated, nil
+}
+
+// notifyResult sends the result to the callback URL and the emails if they are set.
+// Failed notifications are only logged, they don't change the response.
+// The response waits for the notifications, they are stopped when the context is done.
+func notifyResult(ctx context.Context, resultURL string, err error) {
+	notifiers := []notify.Notifier{}
+	if callbackURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifierFromEnv(callbackURL))
+	}
+	if len(notifyEmails) != 0 {
+		n, err := notify.NewSMTPNotifierFromEnv(notifyEmails)
+		if err != nil {
+			log.Printf("Error: %v", err)
+		} else {
+			notifiers = append(notifiers, n)
+		}
+	}
+	if len(notifiers) == 0 {
+		return
+	}
+
+	p := notify.Payload{
+		Status:   notify.StatusCompleted,
+		Type:     rType,
+		Format:   format,
+		Country:  country,
+		Language: language,
+		Counts:   counts,
+		URL:      resultURL,
+		Date:     time.Now().UTC(),
+	}
+	if err != nil {
+		p.Status, p.Error = notify.StatusFailed, err.Error()
+	}
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, p); err != nil {
+			log.Printf("Error: %v", err)
+		}
+	}
+}
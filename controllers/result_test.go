@@ -1,13 +1,31 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/joho/godotenv"
+
+	"github.com/zeoagency/carbon/services/account"
+	"github.com/zeoagency/carbon/services/excel"
+	"github.com/zeoagency/carbon/services/export"
+	"github.com/zeoagency/carbon/services/httpclient"
+	"github.com/zeoagency/carbon/services/keypool"
+	"github.com/zeoagency/carbon/services/notify"
+	"github.com/zeoagency/carbon/services/ratelimit"
+	"github.com/zeoagency/carbon/services/sheet"
 )
 
 func init() {
@@ -20,12 +38,84 @@ func init() {
 func TestResultShouldFail(t *testing.T) {
 	// Check if method control is working.
 	request := events.APIGatewayProxyRequest{}
-	res, _ := Result(request)
+	res, _ := Result(context.Background(), request)
 	if res.StatusCode != http.StatusMethodNotAllowed {
 		t.Fatal("Method checking is not working.")
 	}
 }
 
+func TestErrorResponseShouldBeJSON(t *testing.T) {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":     "url",
+			"format":   "excel",
+			"country":  "tr",
+			"language": "tr",
+			"append":   "cells",
+		},
+	}
+	res, _ := Result(context.Background(), request)
+
+	body := map[string]string{}
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil || body["error"] != `Append must be "tabs" or "rows".` {
+		t.Fatal("Error body is not valid JSON.", res.Body, err)
+	}
+}
+
+func TestLayoutErrorShouldNotChangeTheBody(t *testing.T) {
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":     "url",
+			"format":   "excel",
+			"country":  "tr",
+			"language": "tr",
+			"layout":   `x", "sheetURL": "https://example.com`,
+		},
+		Body: `{"values": [{"value": "https://zeo.org/old"}]}`,
+	}
+	res, _ := Result(context.Background(), request)
+
+	body := map[string]string{}
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil || len(body) != 1 || res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Layout name must not change the body.", res.Body, err)
+	}
+}
+
+func TestInputErrorShouldNotChangeTheBody(t *testing.T) {
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers:    map[string]string{"Content-Type": "text/csv"},
+		QueryStringParameters: map[string]string{
+			"type":     "url",
+			"format":   "excel",
+			"country":  "tr",
+			"language": "tr",
+			"header":   "true",
+			"column":   `x", "sheetURL": "https://example.com`,
+		},
+		Body: "url\nhttps://zeo.org/old\n",
+	}
+	res, _ := Result(context.Background(), request)
+
+	body := map[string]string{}
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil || len(body) != 1 || res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Column name must not change the body.", res.Body, err)
+	}
+	if body["error"] != `Column "`+request.QueryStringParameters["column"]+`" is not found.` {
+		t.Fatal("Column error is not returned.", res.Body)
+	}
+}
+
 func TestExcelResultForURLs(t *testing.T) {
 	request := events.APIGatewayProxyRequest{
 		HTTPMethod: "POST",
@@ -38,7 +128,7 @@ func TestExcelResultForURLs(t *testing.T) {
 		Body: `{"values": [{"value": "https://tools.zeo.org/carbon"}, {"value": "https://zeo.org"}] }`,
 	}
 
-	res, _ := Result(request)
+	res, _ := Result(context.Background(), request)
 	if res.StatusCode != http.StatusCreated {
 		t.Fatal("Error occur while getting excel file.", res.Body)
 	}
@@ -46,6 +136,29 @@ func TestExcelResultForURLs(t *testing.T) {
 	fmt.Println(res.Headers)
 }
 
+func TestReportResultForURLs(t *testing.T) {
+	for format, contentType := range map[string]string{"html": "text/html; charset=utf-8", "pdf": "application/pdf"} {
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			QueryStringParameters: map[string]string{
+				"type":     "url",
+				"format":   format,
+				"country":  "tr",
+				"language": "tr",
+			},
+			Body: `{"values": [{"value": "https://tools.zeo.org/carbon"}, {"value": "https://zeo.org"}] }`,
+		}
+
+		res, _ := Result(context.Background(), request)
+		if res.StatusCode != http.StatusCreated {
+			t.Fatal("Error occur while getting the report.", res.Body)
+		}
+		if res.Headers["Content-Type"] != contentType {
+			t.Fatal("Content type of the report is not valid.", res.Headers)
+		}
+	}
+}
+
 func TestExcelResultForKeywords(t *testing.T) {
 	request := events.APIGatewayProxyRequest{
 		HTTPMethod: "POST",
@@ -60,7 +173,7 @@ func TestExcelResultForKeywords(t *testing.T) {
 		Body: `{"values": [{"value": "zeo carbon tool"}] }`,
 	}
 
-	res, _ := Result(request)
+	res, _ := Result(context.Background(), request)
 	if res.StatusCode != http.StatusCreated {
 		t.Fatal("Error occur while getting excel file.", res.Body)
 	}
@@ -80,7 +193,7 @@ func TestSheetResultForURLs(t *testing.T) {
 		Body: `{"values": [{"value": "https://tools.zeo.org/carbon"}] }`,
 	}
 
-	res, _ := Result(request)
+	res, _ := Result(context.Background(), request)
 	if res.StatusCode != http.StatusCreated {
 		t.Fatal("Error occur while getting excel file.", res.Body)
 	}
@@ -102,10 +215,484 @@ func TestSheetResultForKeywords(t *testing.T) {
 		Body: `{"values": [{"value": "zeo carbon tool"}] }`,
 	}
 
-	res, _ := Result(request)
+	res, _ := Result(context.Background(), request)
 	if res.StatusCode != http.StatusCreated {
 		t.Fatal("Error occur while getting excel file.", res.Body)
 	}
 
 	fmt.Println(res.Body)
 }
+
+// fakeSheetWriter keeps the options of the last write.
+type fakeSheetWriter struct {
+	opts sheet.Options
+	err  error
+}
+
+func (w *fakeSheetWriter) Write(ctx context.Context, r excel.Report, opts sheet.Options) (string, error) {
+	w.opts = opts
+	if w.err != nil {
+		return "", w.err
+	}
+	return "https://docs.google.com/spreadsheets/d/" + opts.SpreadsheetID, nil
+}
+
+func TestSheetResultShouldUseOptions(t *testing.T) {
+	w := &fakeSheetWriter{}
+	sheetWriterOnce.Do(func() {})
+	sheetWriter, sheetWriterErr = w, nil
+	defer func() { sheetWriter, sheetWriterErr = nil, nil; sheetWriterOnce = sync.Once{} }()
+
+	// Existing spreadsheets and folders are only for internal accounts.
+	useInternalAccount(t, "anyone,bora@zeo.org:writer")
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":            "url",
+			"format":          "sheet",
+			"country":         "tr",
+			"language":        "tr",
+			"accountName":     "bora@zeo.org",
+			"accountPassword": "bora",
+			"spreadsheetId":   "client-a",
+			"append":          "rows",
+			"folderId":        "folder-a",
+			"sharing":         "private,bora@zeo.org:writer",
+		},
+		Body: `{"values": [{"value": "notaavalidurl"}] }`,
+	}
+
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatal("Error occur while getting sheet url.", res.Body)
+	}
+	if w.opts.SpreadsheetID != "client-a" || w.opts.Append != "rows" || w.opts.FolderID != "folder-a" {
+		t.Fatal("Sheet options are not passed to the writer.", w.opts)
+	}
+	if w.opts.Sharing.Link != sheet.LinkPrivate || len(w.opts.Sharing.Users) != 1 || w.opts.Sharing.Users[0].Role != "writer" {
+		t.Fatal("Sharing is not passed to the writer.", w.opts.Sharing)
+	}
+
+	request.QueryStringParameters["sharing"] = "everyone"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Sharing must be validated.", res.Body)
+	}
+
+	// The sharing of the account is a ceiling for the request.
+	request.QueryStringParameters["sharing"] = "anyone:writer"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatal("Sharing must not be wider than the account's.", res.Body)
+	}
+	request.QueryStringParameters["sharing"] = ""
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusCreated || len(w.opts.Sharing.Users) != 1 || w.opts.Sharing.Link != sheet.LinkAnyone {
+		t.Fatal("Sharing of the account must be used by default.", res.Body, w.opts.Sharing)
+	}
+
+	request.QueryStringParameters["append"] = "cells"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Append must be validated.", res.Body)
+	}
+
+	// Anonymous requests can't share with users or send notifications.
+	for _, param := range []string{"accountName", "accountPassword", "spreadsheetId", "append", "folderId"} {
+		delete(request.QueryStringParameters, param)
+	}
+	for _, spec := range []string{"client@example.com", "private,notify,client@example.com"} {
+		request.QueryStringParameters["sharing"] = spec
+		res, _ = Result(context.Background(), request)
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatal("Anonymous requests must not share with users.", spec, res.Body)
+		}
+	}
+	request.QueryStringParameters["sharing"] = "private"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusCreated || w.opts.Sharing.Link != sheet.LinkPrivate {
+		t.Fatal("Anonymous requests must narrow the sharing.", res.Body)
+	}
+}
+
+func TestSheetResultShouldSeparateGoogleErrors(t *testing.T) {
+	w := &fakeSheetWriter{}
+	sheetWriterOnce.Do(func() {})
+	sheetWriter, sheetWriterErr = w, nil
+	defer func() { sheetWriter, sheetWriterErr = nil, nil; sheetWriterOnce = sync.Once{} }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":     "url",
+			"format":   "sheet",
+			"country":  "tr",
+			"language": "tr",
+		},
+		Body: `{"values": [{"value": "notaavalidurl"}] }`,
+	}
+
+	tests := []struct {
+		kind   error
+		status int
+	}{
+		{sheet.ErrQuota, http.StatusServiceUnavailable},
+		{sheet.ErrPermission, http.StatusForbidden},
+		{sheet.ErrAuth, http.StatusInternalServerError},
+		{nil, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		w.err = &sheet.Error{Kind: test.kind, Message: "Error occur while creating file on Google Sheets.", Err: errors.New("googleapi")}
+		res, _ := Result(context.Background(), request)
+		if res.StatusCode != test.status {
+			t.Fatal("Status is not valid for the error.", test.kind, res.StatusCode, res.Body)
+		}
+	}
+}
+
+// fakeExporter keeps the last exported file, the query is added to the returned URL.
+type fakeExporter struct {
+	file  export.File
+	query string
+}
+
+func (e *fakeExporter) Export(ctx context.Context, f export.File) (string, error) {
+	e.file = f
+	return "https://files.example.com/" + f.Name + e.query, nil
+}
+
+func TestResultShouldExportToDestination(t *testing.T) {
+	e := &fakeExporter{}
+	exporters[export.DestinationS3] = e
+	defer delete(exporters, export.DestinationS3)
+
+	// Requests of the other tests must not limit this one.
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	// Destinations are only for internal accounts.
+	useInternalAccount(t, "")
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":            "url",
+			"format":          "pdf",
+			"country":         "tr",
+			"language":        "tr",
+			"accountName":     "bora@zeo.org",
+			"accountPassword": "bora",
+			"destination":     "s3",
+		},
+		Body: `{"values": [{"value": "notaavalidurl"}] }`,
+	}
+
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusCreated || res.Body != `{"destination":"s3","fileURL":"https://files.example.com/result.pdf"}` {
+		t.Fatal("Error occur while exporting the file.", res.Body)
+	}
+	if e.file.ContentType != "application/pdf" || !bytes.HasPrefix(e.file.Body, []byte("%PDF-")) {
+		t.Fatal("File is not exported with its type.", e.file.Name, e.file.ContentType)
+	}
+
+	// Links of the destinations can have quotes.
+	e.query = `?name="result"`
+	res, _ = Result(context.Background(), request)
+	body := map[string]string{}
+	if err := json.Unmarshal([]byte(res.Body), &body); err != nil || body["fileURL"] != "https://files.example.com/result.pdf"+e.query {
+		t.Fatal("Body is not valid JSON.", res.Body, err)
+	}
+
+	request.QueryStringParameters["destination"] = "ftp"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Destination must be validated.", res.Body)
+	}
+
+	request.QueryStringParameters["destination"] = "s3"
+	request.QueryStringParameters["format"] = "sheet"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Destination must not be used with sheets.", res.Body)
+	}
+}
+
+func TestResultShouldNotifyCallbackURL(t *testing.T) {
+	payloads := make(chan notify.Payload, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := notify.Payload{}
+		json.NewDecoder(r.Body).Decode(&p)
+		payloads <- p
+	}))
+	defer server.Close()
+
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	// Callbacks are only for internal accounts, the test server is in the private network.
+	useInternalAccount(t, "")
+	os.Setenv("CALLBACK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CALLBACK_ALLOW_PRIVATE")
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":            "url",
+			"format":          "excel",
+			"country":         "tr",
+			"language":        "tr",
+			"accountName":     "bora@zeo.org",
+			"accountPassword": "bora",
+			"callbackURL":     server.URL,
+		},
+		Body: `{"values": [{"value": "notaavalidurl"}] }`,
+	}
+
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatal("Error occur while getting excel file.", res.Body)
+	}
+	p := <-payloads
+	if p.Status != notify.StatusCompleted || p.Type != "url" || p.Counts["total"] != 1 || p.Counts["fail"] != 1 {
+		t.Fatal("Completed result is not notified.", p)
+	}
+
+	request.Body = `{"values": []}`
+	Result(context.Background(), request)
+	p = <-payloads
+	if p.Status != notify.StatusFailed || p.Error != "You don't have any value." {
+		t.Fatal("Failed result is not notified.", p)
+	}
+
+	request.QueryStringParameters["callbackURL"] = "ftp://example.com"
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Callback URL must be validated.", res.Body)
+	}
+
+	os.Unsetenv("CALLBACK_ALLOW_PRIVATE")
+	request.QueryStringParameters["callbackURL"] = server.URL
+	request.Body = `{"values": [{"value": "notaavalidurl"}] }`
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest || len(payloads) != 0 {
+		t.Fatal("Callback URL must not be in the private network.", res.Body)
+	}
+}
+
+func TestResultShouldBeRateLimited(t *testing.T) {
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{
+		Store:     ratelimit.NewMemoryStore(),
+		Anonymous: ratelimit.Limit{Rate: 1, Burst: 1},
+	}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":     "url",
+			"format":   "excel",
+			"country":  "tr",
+			"language": "tr",
+		},
+		Body: `{"values": []}`,
+	}
+	request.RequestContext.Identity.SourceIP = "1.1.1.1"
+
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("First request must not be limited.", res.Body)
+	}
+
+	res, _ = Result(context.Background(), request)
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("Rate limiting is not working.", res.Body)
+	}
+	if res.Headers["Retry-After"] == "" {
+		t.Fatal("Retry-After header is not set.")
+	}
+}
+
+func TestLoginAttemptsShouldBeRateLimited(t *testing.T) {
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{
+		Store: ratelimit.NewMemoryStore(),
+		Login: ratelimit.Limit{Rate: 1, Burst: 2},
+	}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		QueryStringParameters: map[string]string{
+			"type":            "url",
+			"format":          "excel",
+			"country":         "tr",
+			"language":        "tr",
+			"accountName":     "bora@zeo.org",
+			"accountPassword": "wrong-password",
+		},
+		Body: `{"values": []}`,
+	}
+	request.RequestContext.Identity.SourceIP = "2.2.2.2"
+
+	for i := 0; i < 2; i++ {
+		res, _ := Result(context.Background(), request)
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Fatal("Wrong password must be rejected.", res.Body)
+		}
+	}
+
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusTooManyRequests || res.Headers["Retry-After"] == "" {
+		t.Fatal("Login attempts must be limited before the password is checked.", res.Body)
+	}
+}
+
+func TestInternalOptionsShouldBeRejectedForAnonymousUsers(t *testing.T) {
+	limiterOnce.Do(func() {})
+	limiter = &ratelimit.Limiter{Store: ratelimit.NewMemoryStore()}
+	defer func() { limiter = nil; limiterOnce = sync.Once{} }()
+
+	// Emails are validated with the SMTP settings.
+	os.Setenv("SMTP_ADDR", "smtp.example.com:587")
+	os.Setenv("SMTP_FROM", "carbon@example.com")
+	defer os.Unsetenv("SMTP_ADDR")
+	defer os.Unsetenv("SMTP_FROM")
+
+	// Values must be valid, so the requests are not rejected before the check.
+	values := map[string]string{
+		"sitemap":       "https://example.com/sitemap.xml",
+		"precheck":      "true",
+		"spreadsheetId": "abc",
+		"append":        "rows",
+		"folderId":      "abc",
+		"destination":   "s3",
+		"callbackURL":   "https://example.com/cb",
+		"notifyEmail":   "bora@zeo.org",
+	}
+	for _, option := range internalOptions {
+		if values[option] == "" {
+			t.Fatalf("%s doesn't have a test value.", option)
+		}
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			QueryStringParameters: map[string]string{
+				"type":     "url",
+				"format":   "excel",
+				"country":  "tr",
+				"language": "tr",
+				"provider": "sitemap",
+				option:     values[option],
+			},
+			Body: `{"values": [{"value": "https://zeo.org/old"}]}`,
+		}
+		res, _ := Result(context.Background(), request)
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatalf("%s must be only for internal accounts. %s", option, res.Body)
+		}
+	}
+}
+
+func TestAccessLogShouldValidateVerify(t *testing.T) {
+	err := AccessLog([]string{"-path", "access.log", "-host", "zeo.org", "-country", "tr", "-language", "tr", "-verify", "dorp"}, ioutil.Discard)
+	if err == nil || err.Error() != `Verify must be "drop" or "demote".` {
+		t.Fatal("Verify must be validated.", err)
+	}
+}
+
+func TestLookupContextShouldReserveTime(t *testing.T) {
+	os.Setenv("RESULT_RESERVE_SECONDS", "10")
+	defer os.Unsetenv("RESULT_RESERVE_SECONDS")
+
+	for _, test := range []struct {
+		timeout, lookup time.Duration
+	}{
+		{60 * time.Second, 50 * time.Second},
+		{10 * time.Second, 5 * time.Second},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+		lookupCtx, lookupCancel := lookupContext(ctx)
+		deadline, _ := ctx.Deadline()
+		lookupDeadline, ok := lookupCtx.Deadline()
+		lookupCancel()
+		cancel()
+
+		reserve := deadline.Sub(lookupDeadline)
+		if want := test.timeout - test.lookup; !ok || reserve > want || reserve < want-time.Second {
+			t.Fatal("Error: Reserved time issue.", test.timeout, reserve)
+		}
+	}
+
+	lookupCtx, cancel := lookupContext(context.Background())
+	defer cancel()
+	if _, ok := lookupCtx.Deadline(); ok {
+		t.Fatal("Error: Lookups must not have a deadline without the deadline of the request.")
+	}
+}
+
+func TestSerpKeysStatusShouldBeOnlyForLimitlessAccounts(t *testing.T) {
+	accountStoreOnce.Do(func() {})
+	accountStore, _ = account.NewEnvStore(`{"accounts":[` +
+		`{"name":"admin@zeo.org","password":"` + account.HashPassword("admin") + `","limit":-1},` +
+		`{"name":"user@zeo.org","password":"` + account.HashPassword("user") + `","limit":100}]}`)
+	defer func() { accountStore = nil; accountStoreOnce = sync.Once{} }()
+
+	pool := keypool.New([]keypool.Key{{Address: "https://serp.example.com", Key: "secret-key"}})
+	serpKeyPool = func() (*keypool.Pool, error) { return pool, nil }
+	defer func() { serpKeyPool = keypool.Default }()
+
+	client := httpclient.New()
+	client.Retries = 0
+	client.Threshold = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/search", nil)
+	if res, err := client.Do(req); err == nil {
+		res.Body.Close()
+	}
+	providerClient = func() *httpclient.Client { return client }
+	defer func() { providerClient = httpclient.Default }()
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		QueryStringParameters: map[string]string{
+			"status":          "serpKeys",
+			"accountName":     "user@zeo.org",
+			"accountPassword": "user",
+		},
+	}
+	res, _ := Result(context.Background(), request)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatal("Status must be only for limitless accounts.", res.Body)
+	}
+
+	request.QueryStringParameters["accountName"] = "admin@zeo.org"
+	request.QueryStringParameters["accountPassword"] = "admin"
+	res, _ = Result(context.Background(), request)
+	var body struct {
+		Keys      []keypool.Status              `json:"keys"`
+		Endpoints map[string]httpclient.Metrics `json:"endpoints"`
+	}
+	if res.StatusCode != http.StatusOK || json.Unmarshal([]byte(res.Body), &body) != nil {
+		t.Fatal("Status is not returned.", res.Body)
+	}
+	if len(body.Keys) != 1 || body.Keys[0].Key != "****-key" || body.Keys[0].State != keypool.StateHealthy {
+		t.Fatal("Status is not valid, the key must be masked.", res.Body)
+	}
+	if m := body.Endpoints[server.URL+"/search"]; m.Circuit != "open" || m.Requests != 1 {
+		t.Fatal("Metrics of the endpoints are not returned.", res.Body)
+	}
+}
+
+// useInternalAccount sets an account store that only has "bora@zeo.org" with the "bora" password and the sharing.
+func useInternalAccount(t *testing.T, sharing string) {
+	accountStoreOnce.Do(func() {})
+	accountStore, _ = account.NewEnvStore(`{"accounts":[{"name":"bora@zeo.org","password":"` + account.HashPassword("bora") +
+		`","limit":100,"sharing":"` + sharing + `"}]}`)
+	t.Cleanup(func() { accountStore = nil; accountStoreOnce = sync.Once{} })
+}
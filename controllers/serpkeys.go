@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/zeoagency/carbon/services/httpclient"
+	"github.com/zeoagency/carbon/services/keypool"
+)
+
+// serpKeyPool returns the key pool of SERP API, it is replaced in the tests.
+var serpKeyPool = keypool.Default
+
+// providerClient returns the shared client of the providers, it is replaced in the tests.
+var providerClient = httpclient.Default
+
+// isSerpKeysStatus tells whether the request asks the status of the SERP API keys.
+func isSerpKeysStatus(request events.APIGatewayProxyRequest) bool {
+	return request.HTTPMethod == "GET" && request.QueryStringParameters["status"] == "serpKeys"
+}
+
+// serpKeysStatus returns the health of the SERP API keys of the lambda instance,
+// with the metrics and the circuits of the provider endpoints.
+// Only limitless internal accounts can see it, the API keys are masked.
+func serpKeysStatus(request events.APIGatewayProxyRequest) events.APIGatewayProxyResponse {
+	isInternal, iLimit, retryAfter, status, err := checkAuthAndRateLimit(request)
+	if err != nil {
+		return errorResponse(status, err, retryAfter)
+	}
+	if !isInternal || iLimit != -1 {
+		return errorResponse(http.StatusForbidden, errors.New("Only limitless internal accounts can see the status of the SERP API keys."), 0)
+	}
+
+	pool, err := serpKeyPool()
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, err, 0)
+	}
+
+	body, err := json.Marshal(struct {
+		Keys      []keypool.Status              `json:"keys"`
+		Endpoints map[string]httpclient.Metrics `json:"endpoints"`
+	}{pool.Status(), providerClient().Metrics()})
+	if err != nil {
+		return errorResponse(http.StatusInternalServerError, errors.New("We have some issues with the SERP API keys. Please try later."), 0)
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
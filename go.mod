@@ -4,10 +4,15 @@ go 1.15
 
 require (
 	github.com/360EntSecGroup-Skylar/excelize/v2 v2.3.0
+	github.com/alicebob/miniredis/v2 v2.14.1
 	github.com/aws/aws-lambda-go v1.19.0
 	github.com/joho/godotenv v1.3.0
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/schollz/closestmatch v2.1.0+incompatible
-	golang.org/x/net v0.0.0-20200707034311-ab3426394381
+	golang.org/x/image v0.0.0-20201208152932-35266b937fa6
+	golang.org/x/net v0.0.0-20201021035429-f5854403a974
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	google.golang.org/api v0.30.0
+	gopkg.in/yaml.v2 v2.3.0
+	modernc.org/sqlite v1.13.3
 )
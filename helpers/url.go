@@ -44,3 +44,55 @@ func ExtractURL(url string) (string, string, error) {
 
 	return parts[len(parts)-count] + "." + parts[len(parts)-(count-1)], keywords, nil
 }
+
+// trackingParams includes query params that don't change the page.
+var trackingParams = []string{
+	"gclid", "dclid", "fbclid", "msclkid", "yclid", "twclid", "igshid", "_ga", "_gl", "mc_cid", "mc_eid", "ref", "ref_src",
+}
+
+// CanonicalURL returns the URL in a form that is used to compare URLs.
+// Scheme, default ports, host case, trailing slash, fragment and tracking params are ignored,
+// the rest of query params are sorted.
+//
+// Given URL: "HTTP://Boratanrikulu.dev:80/archlinux-install/?utm_source=x&b=2&a=1#top"
+// Result: "boratanrikulu.dev/archlinux-install?a=1&b=2"
+func CanonicalURL(url string) (string, error) {
+	u, err := neturl.Parse(strings.TrimSpace(url))
+	if err != nil || u.Host == "" {
+		return "", errors.New("That's not a valid URL.")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for k := range query {
+		_, tracking := StringSliceContains(trackingParams, strings.ToLower(k))
+		if tracking || strings.HasPrefix(strings.ToLower(k), "utm_") {
+			query.Del(k)
+		}
+	}
+
+	result := host + path
+	if len(query) != 0 {
+		result += "?" + query.Encode() // Encode sorts by key.
+	}
+	return result, nil
+}
+
+// SameURL tells whether both URLs are the same page by comparing their canonical forms.
+func SameURL(a, b string) bool {
+	ca, err := CanonicalURL(a)
+	if err != nil {
+		return false
+	}
+	cb, err := CanonicalURL(b)
+	if err != nil {
+		return false
+	}
+	return ca == cb
+}
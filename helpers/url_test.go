@@ -0,0 +1,25 @@
+package helpers
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	variants := []string{
+		"https://boratanrikulu.dev/archlinux-install?b=2&a=1",
+		"http://boratanrikulu.dev/archlinux-install/?a=1&b=2",
+		"HTTPS://Boratanrikulu.DEV:443/archlinux-install?utm_source=x&a=1&b=2&gclid=y#top",
+	}
+
+	for _, v := range variants {
+		c, err := CanonicalURL(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != "boratanrikulu.dev/archlinux-install?a=1&b=2" {
+			t.Fatalf("Error: Canonical URL issue for %s: %s", v, c)
+		}
+	}
+
+	if SameURL("https://boratanrikulu.dev/archlinux-install", "https://boratanrikulu.dev/archlinux-install?page=2") {
+		t.Fatal("Error: Different pages are the same.")
+	}
+}
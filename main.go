@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/joho/godotenv"
 
@@ -12,5 +15,23 @@ func init() {
 }
 
 func main() {
+	// Commands are run at the local, e.g. `./carbon accounts list`.
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "accounts":
+			err = controllers.Accounts(os.Args[2:], os.Stdout)
+		case "accesslog":
+			err = controllers.AccessLog(os.Args[2:], os.Stdout)
+		default:
+			err = fmt.Errorf("Command \"%s\" is not supported.", os.Args[1])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	lambda.Start(controllers.Result)
 }
@@ -1,6 +1,9 @@
 package models
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
 // KeywordSet is kind a Set Data Structure implementation.
 // It has an Add method that only works if the Keyword doesn't exist already.
@@ -18,6 +21,11 @@ type KeywordSet struct {
 	Keywords  map[string]bool           // the key is the keyword.
 	Successes map[string]keywordSuccess // the key is the keyword.
 	Fails     map[string]keywordFail    // the key is the keyword.
+	Attempts  map[string][]Attempt      // the key is the keyword, lookups of the providers in the order.
+	Meta      Meta
+
+	Order      []string       // keywords in the input order, duplicates are not included.
+	Duplicates map[string]int // the key is the keyword, the value is the count of its duplicates.
 }
 
 // NewKeywordSet inits the KeywordSet to use.
@@ -26,12 +34,16 @@ func NewKeywordSet() *KeywordSet {
 	k.Keywords = make(map[string]bool)
 	k.Successes = make(map[string]keywordSuccess)
 	k.Fails = make(map[string]keywordFail)
+	k.Attempts = make(map[string][]Attempt)
+	k.Duplicates = make(map[string]int)
+	k.Meta = Meta{Type: "keyword", Date: time.Now()}
 	return &k
 }
 
 // keywordSuccess is used to keep the result.
 type keywordSuccess struct {
-	Results []KeywordSuccessResult
+	Results  []KeywordSuccessResult
+	Provider string // name of the provider that found the results.
 }
 
 // KeywordSuccessResults keeps the result for success elements.
@@ -48,6 +60,7 @@ type keywordFail struct {
 }
 
 // Add method adds new keywords if it is doesn't already exist.
+// The input order and the count of duplicates are kept for the exports.
 func (k *KeywordSet) Add(keywords ...string) error {
 	for _, keyword := range keywords {
 		keyword = strings.TrimSpace(keyword)
@@ -55,16 +68,36 @@ func (k *KeywordSet) Add(keywords ...string) error {
 			continue // If the keyword is empty, do nothing.
 		}
 		if _, ok := k.Keywords[keyword]; ok {
-			// Continue if it is already exists.
+			// Count if it is already exists.
+			k.Duplicates[keyword]++
 			continue
 		}
 
 		k.Keywords[keyword] = true
+		k.Order = append(k.Order, keyword)
 	}
 
 	return nil
 }
 
+// SuccessOrder returns keywords of the success list in the input order.
+func (k *KeywordSet) SuccessOrder() []string {
+	keys := []string{}
+	for key := range k.Successes {
+		keys = append(keys, key)
+	}
+	return inOrder(k.Order, keys)
+}
+
+// FailOrder returns keywords of the fail list in the input order.
+func (k *KeywordSet) FailOrder() []string {
+	keys := []string{}
+	for key := range k.Fails {
+		keys = append(keys, key)
+	}
+	return inOrder(k.Order, keys)
+}
+
 // AddSuccess adds the result to the success list, if it doesn't exist already.
 func (k *KeywordSet) AddSuccess(keyword string, results []KeywordSuccessResult) {
 	if _, ok := k.Successes[keyword]; ok {
@@ -76,6 +109,22 @@ func (k *KeywordSet) AddSuccess(keyword string, results []KeywordSuccessResult)
 	}
 }
 
+// SetProvider sets the name of the provider that found the results of the success.
+func (k *KeywordSet) SetProvider(keyword string, provider string) {
+	success, ok := k.Successes[keyword]
+	if !ok {
+		return
+	}
+
+	success.Provider = provider
+	k.Successes[keyword] = success
+}
+
+// AddAttempt adds the lookup of the keyword by a provider.
+func (k *KeywordSet) AddAttempt(keyword string, attempt Attempt) {
+	k.Attempts[keyword] = append(k.Attempts[keyword], attempt)
+}
+
 // AddFail adds the keyword to the fail list with a reason, if it doesn't exist already.
 func (k *KeywordSet) AddFail(keyword string, reason string) {
 	if _, ok := k.Fails[keyword]; ok {
@@ -18,3 +18,12 @@ func TestKeywordSetAdd(t *testing.T) {
 		t.Fatal("Error: Set Data Structure issue.")
 	}
 }
+
+func TestKeywordSetOrderAndDuplicates(t *testing.T) {
+	k := NewKeywordSet()
+
+	k.Add("zeo carbon", "boratanrikulu blog", "zeo carbon")
+	if len(k.Order) != 2 || k.Order[0] != "zeo carbon" || k.Duplicates["zeo carbon"] != 1 {
+		t.Fatal("Error: Order or duplicate issue.", k.Order, k.Duplicates)
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Meta keeps the request metadata that is shown in the summary of the exports.
+type Meta struct {
+	Type      string // "url" or "keyword".
+	Country   string
+	Language  string
+	Date      time.Time
+	Providers []string // names of the providers that are used to find the result.
+	TimedOut  bool     // the deadline is reached before all values are looked up, the result is partial.
+}
+
+// AddProvider adds the provider name, if it doesn't exist already.
+func (m *Meta) AddProvider(name string) {
+	for _, p := range m.Providers {
+		if p == name {
+			return
+		}
+	}
+	m.Providers = append(m.Providers, name)
+}
+
+// Attempt is a lookup of a value by a provider.
+type Attempt struct {
+	Provider string
+	Found    bool   // the provider found a result for the value.
+	Error    string // the provider failed for the value, it is empty if the provider answered.
+}
+
+// String returns a short summary of the attempt, like "SerpApi: no result".
+func (a Attempt) String() string {
+	switch {
+	case a.Error != "":
+		return fmt.Sprintf("%s: error: %s", a.Provider, a.Error)
+	case a.Found:
+		return a.Provider + ": found"
+	}
+	return a.Provider + ": no result"
+}
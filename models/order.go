@@ -0,0 +1,28 @@
+package models
+
+import "sort"
+
+// inOrder returns the keys in the input order.
+// Keys that are not in the order (added without Add method) are added at the end as sorted.
+func inOrder(order []string, keys []string) []string {
+	set := make(map[string]bool)
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	r := []string{}
+	for _, o := range order {
+		if set[o] {
+			r = append(r, o)
+			delete(set, o)
+		}
+	}
+
+	rest := []string{}
+	for k := range set {
+		rest = append(rest, k)
+	}
+	sort.Strings(rest)
+
+	return append(r, rest...)
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zeoagency/carbon/helpers"
 
@@ -21,12 +24,22 @@ import (
 //   "https://boratanrikulu.dev/smtp-nasil-calisir-ve-postfix-kurulumu/",
 // )
 //
-// You can access by using string output,
-// like that: s.URLs["boratanrikulu.dev postgresql nedir nasil calisir"].BaseURL
+// You can access by using the canonical URL,
+// like that: s.URLs["boratanrikulu.dev/postgresql-nedir-nasil-calisir"].BaseURL
 type URLSet struct {
-	URLs      map[string]url        // the key is url.String() (BaseURL + Keywords).
+	URLs      map[string]url        // the key is the canonical URL, see helpers.CanonicalURL.
 	Successes map[string]urlSuccess // the key is the Original URL.
 	Fails     map[string]urlFail    // the key is the Original URL.
+	Hits      map[string]int        // the key is the Original URL, only set for access log inputs.
+	Skips     map[string]urlSkip    // the key is the Original URL.
+	Classes   map[string]string     // the key is the Original URL, only set when inputs are pre-checked.
+	Attempts  map[string][]Attempt  // the key is the Original URL, lookups of the providers in the order.
+	Warnings  []URLWarning
+	Meta      Meta
+
+	Order      []string          // Original URLs in the input order, duplicates are not included.
+	Duplicates map[string]int    // the key is the Original URL, the value is the count of its duplicates.
+	firsts     map[string]string // the key is the canonical URL, the value is the first Original URL.
 }
 
 // NewURLSet inits the URLSet to use.
@@ -35,6 +48,13 @@ func NewURLSet() *URLSet {
 	s.URLs = make(map[string]url)
 	s.Successes = make(map[string]urlSuccess)
 	s.Fails = make(map[string]urlFail)
+	s.Hits = make(map[string]int)
+	s.Skips = make(map[string]urlSkip)
+	s.Classes = make(map[string]string)
+	s.Attempts = make(map[string][]Attempt)
+	s.Duplicates = make(map[string]int)
+	s.firsts = make(map[string]string)
+	s.Meta = Meta{Type: "url", Date: time.Now()}
 	return &s
 }
 
@@ -49,6 +69,59 @@ type url struct {
 type urlSuccess struct {
 	URLs         []string
 	SuggestedURL string
+	Checks       map[string]URLCheck // the key is the alternative URL, only set when alternatives are verified.
+	Provider     string              // name of the provider that found the result.
+}
+
+// URLCheck keeps the result of checking whether an URL is live.
+// It is exported to use in services package.
+type URLCheck struct {
+	Status         int    // the status of the final response.
+	FinalURL       string // the URL after following redirects.
+	Redirects      int
+	RedirectStatus int // the status of the first redirect, like 301 or 302.
+	Canonical      string
+	Title          string
+	Robots         string // meta robots and X-Robots-Tag values.
+	Error          string
+}
+
+// IsLive tells whether the URL can be suggested;
+// it must be 200 after redirects and must not be noindex.
+func (c URLCheck) IsLive() bool {
+	return c.Error == "" && c.Status == 200 && !strings.Contains(strings.ToLower(c.Robots), "noindex")
+}
+
+// String returns a short summary of the check.
+func (c URLCheck) String() string {
+	if c.Error != "" {
+		return "error: " + c.Error
+	}
+
+	r := strconv.Itoa(c.Status)
+	if c.Redirects != 0 {
+		r = fmt.Sprintf("%d after %d redirect(s) to %s", c.Status, c.Redirects, c.FinalURL)
+	}
+	if strings.Contains(strings.ToLower(c.Robots), "noindex") {
+		r += ", noindex"
+	}
+	if c.Canonical != "" && c.Canonical != c.FinalURL {
+		r += ", canonical: " + c.Canonical
+	}
+	return r
+}
+
+// URLWarning keeps an issue with the suggestions in the batch, like redirect chains or loops.
+// It is exported to use in services package.
+type URLWarning struct {
+	URL    string // the Original URL.
+	Type   string
+	Detail string
+}
+
+// urlSkip is used to keep urls that are not looked up, since they are not broken.
+type urlSkip struct {
+	Reason string
 }
 
 // urlFail is used to keep urls that could not be processed with a reason.
@@ -57,28 +130,83 @@ type urlFail struct {
 }
 
 // Add method adds new URLs if it is doesn't exist already.
+// URLs are compared by their canonical forms, so variants like "http://", trailing slash
+// or tracking params are added only once.
 // Also, It splits the url to FullURL, BaseURL and Keywords.
 // It except only valid URLs.
 // If there is an issue with the given URL, It adds it to the fail list with a reason.
+// The input order and the count of duplicates are kept for the exports.
 func (s *URLSet) Add(urls ...string) {
 	for _, url := range urls {
 		url = strings.TrimSpace(url)
 		if url == "" {
 			continue // If the url is empty, do nothing.
 		}
+
+		canonical, canonicalErr := helpers.CanonicalURL(url)
+		if canonicalErr != nil {
+			canonical = url // Invalid URLs are compared as they are.
+		}
+		if first, ok := s.firsts[canonical]; ok {
+			// Count if it is already exists.
+			s.Duplicates[first]++
+			continue
+		}
+		s.firsts[canonical] = url
+		s.Order = append(s.Order, url)
+
 		u, err := convertToURL(url)
-		if err != nil {
+		if err != nil || canonicalErr != nil {
 			s.AddFail(url, "That's not an URL.")
 			continue
 		}
 
-		if _, ok := s.URLs[u.String()]; ok {
-			// Continue if it is already exists.
-			continue
+		s.URLs[canonical] = u
+	}
+}
+
+// SuccessOrder returns Original URLs of the success list in the input order.
+func (s *URLSet) SuccessOrder() []string {
+	keys := []string{}
+	for k := range s.Successes {
+		keys = append(keys, k)
+	}
+	return inOrder(s.Order, keys)
+}
+
+// FailOrder returns Original URLs of the fail list in the input order.
+func (s *URLSet) FailOrder() []string {
+	keys := []string{}
+	for k := range s.Fails {
+		keys = append(keys, k)
+	}
+	return inOrder(s.Order, keys)
+}
+
+// InputOrder returns Original URLs of all lists (success, fail and skip) in the input order.
+func (s *URLSet) InputOrder() []string {
+	keys := []string{}
+	for k := range s.Successes {
+		keys = append(keys, k)
+	}
+	for k := range s.Fails {
+		if _, ok := s.Successes[k]; !ok {
+			keys = append(keys, k)
 		}
+	}
+	for k := range s.Skips {
+		keys = append(keys, k)
+	}
+	return inOrder(s.Order, keys)
+}
 
-		s.URLs[u.String()] = u
+// SkipOrder returns Original URLs of the skip list in the input order.
+func (s *URLSet) SkipOrder() []string {
+	keys := []string{}
+	for k := range s.Skips {
+		keys = append(keys, k)
 	}
+	return inOrder(s.Order, keys)
 }
 
 // AddSuccess adds the result to the success list, if it doesn't exist already.
@@ -94,6 +222,35 @@ func (s *URLSet) AddSuccess(originalURL string, result []string) {
 	}
 }
 
+// SetChecks updates the alternatives of the success with the verified ones.
+// The given URLs replace the alternatives, and the suggestion is made again by using live URLs.
+// If there is no live URL, the url is moved to the fail list with the reason, so it is not redirected to a dead page.
+func (s *URLSet) SetChecks(originalURL string, urls []string, checks map[string]URLCheck, reason string) {
+	if _, ok := s.Successes[originalURL]; !ok {
+		return
+	}
+
+	live := []string{}
+	for _, u := range urls {
+		if checks[u].IsLive() {
+			live = append(live, u)
+		}
+	}
+	if len(live) == 0 {
+		delete(s.Successes, originalURL)
+		s.AddFail(originalURL, reason)
+		return
+	}
+
+	c := closestmatch.New(live, []int{2})
+	s.Successes[originalURL] = urlSuccess{
+		URLs:         urls,
+		SuggestedURL: c.Closest(originalURL),
+		Checks:       checks,
+		Provider:     s.Successes[originalURL].Provider,
+	}
+}
+
 // AddFail adds the url to the fail list with a reason, if it doesn't exist already.
 func (s *URLSet) AddFail(originalURL string, reason string) {
 	if _, ok := s.Fails[originalURL]; ok {
@@ -105,6 +262,65 @@ func (s *URLSet) AddFail(originalURL string, reason string) {
 	}
 }
 
+// SetProvider sets the name of the provider that found the result of the success.
+func (s *URLSet) SetProvider(originalURL string, provider string) {
+	success, ok := s.Successes[originalURL]
+	if !ok {
+		return
+	}
+
+	success.Provider = provider
+	s.Successes[originalURL] = success
+}
+
+// AddAttempt adds the lookup of the url by a provider.
+func (s *URLSet) AddAttempt(originalURL string, attempt Attempt) {
+	s.Attempts[originalURL] = append(s.Attempts[originalURL], attempt)
+}
+
+// SetSuggestion replaces the suggested URL of the success.
+func (s *URLSet) SetSuggestion(originalURL string, suggestedURL string) {
+	success, ok := s.Successes[originalURL]
+	if !ok {
+		return
+	}
+
+	success.SuggestedURL = suggestedURL
+	s.Successes[originalURL] = success
+}
+
+// AddWarning adds a warning for the url.
+func (s *URLSet) AddWarning(originalURL, warningType, detail string) {
+	s.Warnings = append(s.Warnings, URLWarning{
+		URL:    originalURL,
+		Type:   warningType,
+		Detail: detail,
+	})
+}
+
+// AddSkip removes the url from the urls to look up, and adds it to the skip list with a reason.
+func (s *URLSet) AddSkip(originalURL string, reason string) {
+	for k, u := range s.URLs {
+		if u.FullURL == originalURL {
+			delete(s.URLs, k)
+		}
+	}
+
+	s.Skips[originalURL] = urlSkip{
+		Reason: reason,
+	}
+}
+
+// SetClass sets the pre-check classification of the url, like "Not Found (404)".
+func (s *URLSet) SetClass(originalURL string, class string) {
+	s.Classes[originalURL] = class
+}
+
+// AddHits adds the request count of the url that is taken from an access log.
+func (s *URLSet) AddHits(originalURL string, count int) {
+	s.Hits[strings.TrimSpace(originalURL)] += count
+}
+
 // convertToURL converts the given string to a parsed URL.
 //
 // For example;
@@ -128,12 +344,17 @@ func convertToURL(fullURL string) (url, error) {
 	return result, nil
 }
 
-// ToStringSlice create a string slice that includes all URLs.
+// ToStringSlice create a string slice that includes search keys of all URLs.
+// The search key is url.String() (BaseURL + Keywords), it is unique in the slice.
 func (u *URLSet) ToStringSlice() []string {
 	r := []string{}
+	seen := make(map[string]bool)
 
-	for k, _ := range u.URLs {
-		r = append(r, k)
+	for _, url := range u.URLs {
+		if !seen[url.String()] {
+			seen[url.String()] = true
+			r = append(r, url.String())
+		}
 	}
 
 	return r
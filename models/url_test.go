@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -34,3 +35,43 @@ func TestUrlSetAddShouldFail(t *testing.T) {
 		t.Fatal("Error: Fail list issue.")
 	}
 }
+
+func TestUrlSetAddCanonical(t *testing.T) {
+	s := NewURLSet()
+
+	s.Add(
+		"https://boratanrikulu.dev/archlinux-install",
+		"http://boratanrikulu.dev/archlinux-install/",
+		"https://Boratanrikulu.dev/archlinux-install?utm_source=newsletter",
+	)
+	if len(s.URLs) != 1 {
+		t.Fatal("Error: Canonical URL issue.")
+	}
+}
+
+func TestUrlSetOrderAndDuplicates(t *testing.T) {
+	s := NewURLSet()
+
+	s.Add(
+		"https://tools.zeo.org/carbon",
+		"aaaaa",
+		"https://boratanrikulu.dev/archlinux-install",
+		"https://tools.zeo.org/carbon/",
+		"aaaaa",
+		"https://tools.zeo.org/carbon",
+	)
+
+	expected := []string{"https://tools.zeo.org/carbon", "aaaaa", "https://boratanrikulu.dev/archlinux-install"}
+	if !reflect.DeepEqual(s.Order, expected) {
+		t.Fatal("Error: Order issue.", s.Order)
+	}
+	if s.Duplicates["https://tools.zeo.org/carbon"] != 2 || s.Duplicates["aaaaa"] != 1 {
+		t.Fatal("Error: Duplicate count issue.", s.Duplicates)
+	}
+
+	s.AddSuccess("https://boratanrikulu.dev/archlinux-install", []string{"https://boratanrikulu.dev/archlinux"})
+	s.AddSuccess("https://tools.zeo.org/carbon", []string{"https://tools.zeo.org/"})
+	if !reflect.DeepEqual(s.SuccessOrder(), []string{"https://tools.zeo.org/carbon", "https://boratanrikulu.dev/archlinux-install"}) {
+		t.Fatal("Error: Success order issue.", s.SuccessOrder())
+	}
+}
@@ -0,0 +1,99 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNotFound is returned when the account doesn't exist in the store.
+var ErrNotFound = errors.New("Account is not found.")
+
+// ErrReadOnly is returned when the store doesn't support write operations.
+var ErrReadOnly = errors.New("Account store is read-only.")
+
+// Account keeps an internal account.
+type Account struct {
+	Name     string `json:"name" yaml:"name"`
+	Password string `json:"password" yaml:"password"` // SHA256 hash of the password.
+	Limit    int    `json:"limit" yaml:"limit"`       // "-1" means there is no limit.
+	Disabled bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Layout   string `json:"layout,omitempty" yaml:"layout,omitempty"`   // the workbook layout, the default one is used if it is empty.
+	Sharing  string `json:"sharing,omitempty" yaml:"sharing,omitempty"` // the sharing spec of Google Sheets, see sheet.ParseSharing.
+}
+
+// Store is implemented by all account backends.
+// It is used to find internal accounts while authorizing requests.
+type Store interface {
+	Find(name string) (Account, error)
+	List() ([]Account, error)
+}
+
+// WritableStore is a Store that supports admin operations.
+type WritableStore interface {
+	Store
+	Add(a Account) error
+	SetDisabled(name string, disabled bool) error
+	SetPassword(name, passwordHash string) error
+	SetLayout(name, layout string) error
+	SetSharing(name, sharing string) error
+}
+
+// NewStoreFromEnv creates the store that is defined by ACCOUNT_STORE.
+//
+// Options:
+//
+//	"env" (default): reads INTERNAL_ACCOUNTS_JSON.
+//	"file": reads the YAML or JSON file at ACCOUNT_STORE_PATH.
+//	"sqlite": uses the SQLite database at ACCOUNT_STORE_PATH.
+func NewStoreFromEnv() (Store, error) {
+	path := os.Getenv("ACCOUNT_STORE_PATH")
+
+	switch os.Getenv("ACCOUNT_STORE") {
+	case "", "env":
+		return NewEnvStore(os.Getenv("INTERNAL_ACCOUNTS_JSON"))
+	case "file":
+		return NewFileStore(path)
+	case "sqlite":
+		return NewSQLiteStore(path)
+	default:
+		return nil, fmt.Errorf("Account store \"%s\" is not supported.", os.Getenv("ACCOUNT_STORE"))
+	}
+}
+
+// Authenticate returns the account if the name and the password are valid.
+// Disabled accounts are never authenticated.
+func Authenticate(s Store, name, password string) (Account, bool, error) {
+	a, err := s.Find(name)
+	if err == ErrNotFound {
+		return Account{}, false, nil
+	}
+	if err != nil {
+		return Account{}, false, err
+	}
+
+	if a.Disabled || a.Password != HashPassword(password) {
+		return Account{}, false, nil
+	}
+
+	return a, true, nil
+}
+
+// HashPassword returns SHA256 hash of the given password as hex.
+func HashPassword(password string) string {
+	h := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(h[:])
+}
+
+// GeneratePassword returns a random password to use while rotating.
+func GeneratePassword() (string, error) {
+	b := make([]byte, 18)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package account
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvStore(t *testing.T) {
+	s, err := NewEnvStore(`{"accounts":[{"name": "bora@zeo.org", "password": "` + HashPassword("12341234") + `", "limit":-1}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, ok, err := Authenticate(s, "bora@zeo.org", "12341234")
+	if err != nil || !ok {
+		t.Fatal("Error: Authentication issue.", err)
+	}
+	if a.Limit != -1 {
+		t.Fatal("Error: Limit issue.")
+	}
+
+	_, ok, _ = Authenticate(s, "bora@zeo.org", "wrong")
+	if ok {
+		t.Fatal("Error: Wrong password is accepted.")
+	}
+}
+
+func TestFileStoreHotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.yaml")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, _ := Authenticate(s, "bora@zeo.org", "12341234")
+	if ok {
+		t.Fatal("Error: Unknown account is accepted.")
+	}
+
+	// Edit the file from outside of the store.
+	content := "accounts:\n  - name: bora@zeo.org\n    password: " + HashPassword("12341234") + "\n    limit: 500\n"
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(path, future, future)
+
+	a, ok, err := Authenticate(s, "bora@zeo.org", "12341234")
+	if err != nil || !ok {
+		t.Fatal("Error: File is not reloaded.", err)
+	}
+	if a.Limit != 500 {
+		t.Fatal("Error: Limit issue.")
+	}
+}
+
+func TestWritableStores(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonStore, err := NewFileStore(filepath.Join(dir, "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqliteStore, err := NewSQLiteStore(filepath.Join(dir, "accounts.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []WritableStore{jsonStore, sqliteStore} {
+		err := s.Add(Account{Name: "bora@zeo.org", Password: HashPassword("12341234"), Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Add(Account{Name: "bora@zeo.org"}) == nil {
+			t.Fatal("Error: Duplicated account is added.")
+		}
+
+		err = s.SetDisabled("bora@zeo.org", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok, _ := Authenticate(s, "bora@zeo.org", "12341234")
+		if ok {
+			t.Fatal("Error: Disabled account is accepted.")
+		}
+
+		err = s.SetDisabled("bora@zeo.org", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.SetPassword("bora@zeo.org", HashPassword("rotated"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok, _ = Authenticate(s, "bora@zeo.org", "rotated")
+		if !ok {
+			t.Fatal("Error: Rotated password is not accepted.")
+		}
+
+		err = s.SetLayout("bora@zeo.org", "client-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := s.Find("bora@zeo.org")
+		if err != nil || a.Layout != "client-a" {
+			t.Fatal("Error: Layout is not set.", err)
+		}
+
+		err = s.SetSharing("bora@zeo.org", "private,bora@zeo.org:writer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err = s.Find("bora@zeo.org")
+		if err != nil || a.Sharing != "private,bora@zeo.org:writer" {
+			t.Fatal("Error: Sharing is not set.", err)
+		}
+
+		if s.SetDisabled("nobody", true) != ErrNotFound {
+			t.Fatal("Error: Unknown account is updated.")
+		}
+
+		accounts, err := s.List()
+		if err != nil || len(accounts) != 1 {
+			t.Fatal("Error: List issue.", err)
+		}
+	}
+}
+
+func TestSQLiteStoreShouldAddLayoutColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE accounts (name TEXT PRIMARY KEY, password TEXT NOT NULL, lim INTEGER NOT NULL DEFAULT 0, disabled INTEGER NOT NULL DEFAULT 0)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO accounts (name, password, lim) VALUES ('bora@zeo.org', 'hash', 10)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := s.Find("bora@zeo.org")
+	if err != nil || a.Limit != 10 || a.Layout != "" {
+		t.Fatal("Error: Old database issue.", a, err)
+	}
+}
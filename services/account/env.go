@@ -0,0 +1,54 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// envStore keeps accounts that are parsed from INTERNAL_ACCOUNTS_JSON.
+// It is read-only, the env value is parsed only once.
+type envStore struct {
+	accounts []Account
+}
+
+// NewEnvStore creates a read-only store from the given JSON value.
+//
+// The value must be like that:
+// {"accounts":[{"name": "bora@zeo.org", "password": "SHA256_PASSWORD", "limit":-1}]}
+func NewEnvStore(value string) (Store, error) {
+	s := &envStore{}
+	if value == "" {
+		return s, nil
+	}
+
+	var v struct {
+		Accounts []Account `json:"accounts"`
+	}
+	err := json.Unmarshal([]byte(value), &v)
+	if err != nil {
+		return nil, errors.New("Unable to parse internal accounts.")
+	}
+	s.accounts = v.Accounts
+
+	return s, nil
+}
+
+// Find returns the account that has the given name.
+func (s *envStore) Find(name string) (Account, error) {
+	return findAccount(s.accounts, name)
+}
+
+// List returns all accounts.
+func (s *envStore) List() ([]Account, error) {
+	return append([]Account{}, s.accounts...), nil
+}
+
+// findAccount returns the account that has the given name from the list.
+func findAccount(accounts []Account, name string) (Account, error) {
+	for _, a := range accounts {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return Account{}, ErrNotFound
+}
@@ -0,0 +1,236 @@
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fileStore keeps accounts in a YAML or JSON file.
+// The file is reloaded when it is changed, so there is no need to redeploy.
+//
+// The file must be like that (as YAML):
+// accounts:
+//   - name: bora@zeo.org
+//     password: SHA256_PASSWORD
+//     limit: -1
+type fileStore struct {
+	path string
+
+	mu       sync.Mutex
+	accounts []Account
+	modTime  time.Time
+	size     int64
+}
+
+// fileContent is the structure of the accounts file.
+type fileContent struct {
+	Accounts []Account `json:"accounts" yaml:"accounts"`
+}
+
+// NewFileStore creates a store for the given file.
+// The format is chosen by the extension; ".json" for JSON, others for YAML.
+// If the file doesn't exist, it is created when an account is added.
+func NewFileStore(path string) (WritableStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Account file path is not set.")
+	}
+
+	s := &fileStore{path: path}
+	err := s.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Find returns the account that has the given name.
+func (s *fileStore) Find(name string) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.reload()
+	if err != nil {
+		return Account{}, err
+	}
+
+	return findAccount(s.accounts, name)
+}
+
+// List returns all accounts.
+func (s *fileStore) List() ([]Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Account{}, s.accounts...), nil
+}
+
+// Add adds the account to the file, if it doesn't exist already.
+func (s *fileStore) Add(a Account) error {
+	return s.update(func(accounts []Account) ([]Account, error) {
+		if _, err := findAccount(accounts, a.Name); err == nil {
+			return nil, fmt.Errorf("Account \"%s\" already exists.", a.Name)
+		}
+		return append(accounts, a), nil
+	})
+}
+
+// SetDisabled disables or enables the account.
+func (s *fileStore) SetDisabled(name string, disabled bool) error {
+	return s.update(func(accounts []Account) ([]Account, error) {
+		for i := range accounts {
+			if accounts[i].Name == name {
+				accounts[i].Disabled = disabled
+				return accounts, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// SetPassword updates the password hash of the account.
+func (s *fileStore) SetPassword(name, passwordHash string) error {
+	return s.update(func(accounts []Account) ([]Account, error) {
+		for i := range accounts {
+			if accounts[i].Name == name {
+				accounts[i].Password = passwordHash
+				return accounts, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// SetLayout updates the workbook layout of the account.
+func (s *fileStore) SetLayout(name, layout string) error {
+	return s.update(func(accounts []Account) ([]Account, error) {
+		for i := range accounts {
+			if accounts[i].Name == name {
+				accounts[i].Layout = layout
+				return accounts, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// SetSharing updates the sharing spec of the account.
+func (s *fileStore) SetSharing(name, sharing string) error {
+	return s.update(func(accounts []Account) ([]Account, error) {
+		for i := range accounts {
+			if accounts[i].Name == name {
+				accounts[i].Sharing = sharing
+				return accounts, nil
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
+
+// update applies the given change to the latest accounts and writes them to the file.
+func (s *fileStore) update(change func([]Account) ([]Account, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.reload()
+	if err != nil {
+		return err
+	}
+
+	accounts, err := change(append([]Account{}, s.accounts...))
+	if err != nil {
+		return err
+	}
+
+	b, err := s.marshal(fileContent{Accounts: accounts})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it, so readers never see a half-written file.
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".accounts-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), s.path)
+	if err != nil {
+		return err
+	}
+
+	// Force reloading on the next call.
+	s.modTime = time.Time{}
+	return s.reload()
+}
+
+// reload reads the file again if it is changed after the last read.
+// The caller must hold the lock.
+func (s *fileStore) reload() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.accounts = nil
+		s.modTime, s.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil // Not changed.
+	}
+
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	var c fileContent
+	if s.isJSON() {
+		err = json.Unmarshal(b, &c)
+	} else {
+		err = yaml.Unmarshal(b, &c)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to parse the account file: %s", err)
+	}
+
+	s.accounts = c.Accounts
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// marshal encodes the content in the file's format.
+func (s *fileStore) marshal(c fileContent) ([]byte, error) {
+	if s.isJSON() {
+		return json.MarshalIndent(c, "", "  ")
+	}
+	return yaml.Marshal(c)
+}
+
+// isJSON tells whether the file is a JSON file.
+func (s *fileStore) isJSON() bool {
+	return strings.ToLower(filepath.Ext(s.path)) == ".json"
+}
@@ -0,0 +1,164 @@
+package account
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore keeps accounts in a SQLite database.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at the given path.
+// The accounts table is created if it doesn't exist already.
+func NewSQLiteStore(path string) (WritableStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Account database path is not set.")
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS accounts (
+		name     TEXT PRIMARY KEY,
+		password TEXT NOT NULL,
+		lim      INTEGER NOT NULL DEFAULT 0,
+		disabled INTEGER NOT NULL DEFAULT 0,
+		layout   TEXT NOT NULL DEFAULT '',
+		sharing  TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Layout and sharing columns are added later, older databases don't have them.
+	for _, column := range []string{"layout", "sharing"} {
+		err = addColumnIfNotExists(db, "accounts", column, `TEXT NOT NULL DEFAULT ''`)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+// Find returns the account that has the given name.
+func (s *sqliteStore) Find(name string) (Account, error) {
+	a := Account{}
+	err := s.db.QueryRow(
+		`SELECT name, password, lim, disabled, layout, sharing FROM accounts WHERE name = ?`, name,
+	).Scan(&a.Name, &a.Password, &a.Limit, &a.Disabled, &a.Layout, &a.Sharing)
+	if err == sql.ErrNoRows {
+		return Account{}, ErrNotFound
+	}
+	if err != nil {
+		return Account{}, err
+	}
+	return a, nil
+}
+
+// List returns all accounts ordered by name.
+func (s *sqliteStore) List() ([]Account, error) {
+	rows, err := s.db.Query(`SELECT name, password, lim, disabled, layout, sharing FROM accounts ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r := []Account{}
+	for rows.Next() {
+		a := Account{}
+		err := rows.Scan(&a.Name, &a.Password, &a.Limit, &a.Disabled, &a.Layout, &a.Sharing)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, a)
+	}
+
+	return r, rows.Err()
+}
+
+// Add adds the account, if it doesn't exist already.
+func (s *sqliteStore) Add(a Account) error {
+	if _, err := s.Find(a.Name); err == nil {
+		return fmt.Errorf("Account \"%s\" already exists.", a.Name)
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO accounts (name, password, lim, disabled, layout, sharing) VALUES (?, ?, ?, ?, ?, ?)`,
+		a.Name, a.Password, a.Limit, a.Disabled, a.Layout, a.Sharing,
+	)
+	return err
+}
+
+// SetDisabled disables or enables the account.
+func (s *sqliteStore) SetDisabled(name string, disabled bool) error {
+	return s.exec(`UPDATE accounts SET disabled = ? WHERE name = ?`, disabled, name)
+}
+
+// SetPassword updates the password hash of the account.
+func (s *sqliteStore) SetPassword(name, passwordHash string) error {
+	return s.exec(`UPDATE accounts SET password = ? WHERE name = ?`, passwordHash, name)
+}
+
+// SetLayout updates the workbook layout of the account.
+func (s *sqliteStore) SetLayout(name, layout string) error {
+	return s.exec(`UPDATE accounts SET layout = ? WHERE name = ?`, layout, name)
+}
+
+// SetSharing updates the sharing spec of the account.
+func (s *sqliteStore) SetSharing(name, sharing string) error {
+	return s.exec(`UPDATE accounts SET sharing = ? WHERE name = ?`, sharing, name)
+}
+
+// exec runs the update query, returns ErrNotFound if there is no affected row.
+func (s *sqliteStore) exec(query string, args ...interface{}) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// addColumnIfNotExists adds the column to the table, if it doesn't exist already.
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk)
+		if err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
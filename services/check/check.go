@@ -0,0 +1,205 @@
+package check
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/publicnet"
+)
+
+// maxBodySize limits the body that is read to find canonical and meta robots.
+const maxBodySize = 512 * 1024
+
+// maxRedirects limits redirects to follow.
+const maxRedirects = 10
+
+// Checker checks URLs with bounded concurrency.
+type Checker struct {
+	Client      *http.Client
+	Concurrency int
+}
+
+// NewChecker creates a checker, the concurrency is taken from CHECK_CONCURRENCY (default 10).
+// Only public addresses are checked, redirects to private ones fail; unless CHECK_ALLOW_PRIVATE is "true", for local development.
+func NewChecker() *Checker {
+	concurrency, err := strconv.Atoi(os.Getenv("CHECK_CONCURRENCY"))
+	if err != nil || concurrency <= 0 {
+		concurrency = 10
+	}
+
+	var transport http.RoundTripper = publicnet.Transport()
+	if os.Getenv("CHECK_ALLOW_PRIVATE") == "true" {
+		transport = http.DefaultTransport
+	}
+
+	return &Checker{
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+		},
+		Concurrency: concurrency,
+	}
+}
+
+// CheckAll checks all given URLs, the result's key is the URL.
+// URLs that are not checked before the context is done are not in the result.
+func (c *Checker) CheckAll(ctx context.Context, urls []string) map[string]models.URLCheck {
+	result := make(map[string]models.URLCheck)
+	mu := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+	sem := make(chan struct{}, c.Concurrency)
+
+	unique := make(map[string]bool)
+loop:
+	for _, u := range urls {
+		if unique[u] {
+			continue
+		}
+		unique[u] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r := c.Check(ctx, u)
+			if r.Error != "" && ctx.Err() != nil {
+				return // The check is cancelled, it is not a result of the URL.
+			}
+			mu.Lock()
+			result[u] = r
+			mu.Unlock()
+		}(u)
+	}
+
+	wg.Wait()
+	return result
+}
+
+// Check follows redirects of the URL, records the final status, canonical and robots.
+// HEAD is tried first, GET is used for HTML pages to read the head of the page,
+// or if the server doesn't allow HEAD.
+func (c *Checker) Check(ctx context.Context, u string) models.URLCheck {
+	r := models.URLCheck{}
+
+	res, redirects, redirectStatus, err := c.do(ctx, "HEAD", u)
+	if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented || isHTML(res)) {
+		res.Body.Close()
+		res, redirects, redirectStatus, err = c.do(ctx, "GET", u)
+	}
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer res.Body.Close()
+
+	r.Status = res.StatusCode
+	r.FinalURL = res.Request.URL.String()
+	r.Redirects = redirects
+	r.RedirectStatus = redirectStatus
+	r.Robots = res.Header.Get("X-Robots-Tag")
+
+	if res.Request.Method == "GET" && isHTML(res) {
+		canonical, robots, title := parseHead(io.LimitReader(res.Body, maxBodySize))
+		r.Canonical = canonical
+		r.Title = title
+		if robots != "" {
+			if r.Robots != "" {
+				r.Robots += ", "
+			}
+			r.Robots += robots
+		}
+	}
+
+	return r
+}
+
+// do sends the request by following redirects,
+// returns the final response with the redirect count and the status of the first redirect.
+func (c *Checker) do(ctx context.Context, method, u string) (*http.Response, int, int, error) {
+	redirects, redirectStatus := 0, 0
+	client := *c.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) == 1 && req.Response != nil {
+			redirectStatus = req.Response.StatusCode
+		}
+		redirects = len(via)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Carbon/1.0; +https://tools.zeo.org/carbon)")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return res, redirects, redirectStatus, nil
+}
+
+// isHTML tells whether the response is an HTML page.
+func isHTML(res *http.Response) bool {
+	return strings.Contains(res.Header.Get("Content-Type"), "text/html")
+}
+
+// parseHead returns the canonical URL, the meta robots value and the title of the page.
+func parseHead(r io.Reader) (string, string, string) {
+	canonical, robots, title := "", "", ""
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return canonical, robots, title
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return canonical, robots, title
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "title" && z.Next() == html.TextToken {
+				title = strings.TrimSpace(string(z.Text()))
+				continue
+			}
+			if !hasAttr || (string(name) != "link" && string(name) != "meta") {
+				continue
+			}
+
+			attrs := make(map[string]string)
+			for {
+				k, v, more := z.TagAttr()
+				attrs[string(k)] = string(v)
+				if !more {
+					break
+				}
+			}
+
+			if string(name) == "link" && strings.EqualFold(attrs["rel"], "canonical") {
+				canonical = attrs["href"]
+			}
+			if string(name) == "meta" && strings.EqualFold(attrs["name"], "robots") {
+				robots = attrs["content"]
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package check
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// newTestServer creates a site that has live, broken, redirected and noindex pages.
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><link rel="canonical" href="https://zeo.org/live"></head><body></body></html>`))
+	})
+	mux.HandleFunc("/noindex", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta name="robots" content="noindex, follow"/></head></html>`))
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/older", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/older", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/live", http.StatusFound)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCheck(t *testing.T) {
+	os.Setenv("CHECK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CHECK_ALLOW_PRIVATE")
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	c := NewChecker()
+	results := c.CheckAll(context.Background(), []string{
+		ts.URL + "/live",
+		ts.URL + "/noindex",
+		ts.URL + "/old",
+		ts.URL + "/missing",
+		ts.URL + "/no-head",
+		ts.URL + "/live",
+	})
+
+	if len(results) != 5 {
+		t.Fatal("Error: Duplicated URLs are checked.", results)
+	}
+
+	live := results[ts.URL+"/live"]
+	if !live.IsLive() || live.Canonical != "https://zeo.org/live" {
+		t.Fatal("Error: Live page issue.", live)
+	}
+
+	if results[ts.URL+"/noindex"].IsLive() {
+		t.Fatal("Error: Noindex page is live.")
+	}
+
+	old := results[ts.URL+"/old"]
+	if old.Redirects != 2 || old.FinalURL != ts.URL+"/live" || old.Status != 200 {
+		t.Fatal("Error: Redirect issue.", old)
+	}
+
+	if results[ts.URL+"/missing"].Status != 404 || results[ts.URL+"/missing"].IsLive() {
+		t.Fatal("Error: Missing page is live.")
+	}
+
+	if !results[ts.URL+"/no-head"].IsLive() {
+		t.Fatal("Error: GET fallback issue.", results[ts.URL+"/no-head"])
+	}
+}
+
+func TestCheckAllShouldStopWhenContextIsDone(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewChecker().CheckAll(ctx, []string{ts.URL + "/live", ts.URL + "/missing"})
+	if len(results) != 0 {
+		t.Fatal("Error: Cancelled checks are in the result.", results)
+	}
+}
+
+func TestCheckShouldNotDialPrivateAddresses(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer ts.Close()
+
+	for _, u := range []string{ts.URL + "/live", "http://169.254.169.254/latest/meta-data", "http://[::1]/"} {
+		r := NewChecker().Check(context.Background(), u)
+		if r.Error == "" || r.IsLive() {
+			t.Fatal("Error: Private addresses must not be checked.", u, r)
+		}
+	}
+	if hits != 0 {
+		t.Fatal("Error: Private address is dialed.", hits)
+	}
+}
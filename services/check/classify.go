@@ -0,0 +1,71 @@
+package check
+
+import (
+	neturl "net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+// softNotFoundTitle matches titles of pages that say "not found" with 200 status.
+var softNotFoundTitle = regexp.MustCompile(`(?i)\b404\b|not found|page (does not|doesn't) exist|sayfa bulunamad|seite nicht gefunden|page introuvable|p[aá]gina no encontrada`)
+
+// Classify returns the class of the checked input URL, like "Not Found (404)".
+// It also tells whether the URL is healthy, so there is no need to look up alternatives.
+//
+// Redirects are healthy only if they end with a live page.
+// Pages that are 200 but redirected to the home page or titled as "not found" are soft 404s.
+func Classify(original string, c models.URLCheck) (string, bool) {
+	if c.Error != "" {
+		return "Unreachable", false
+	}
+
+	class := statusClass(c.Status)
+	healthy := c.Status >= 200 && c.Status <= 299
+	if healthy && isSoftNotFound(original, c) {
+		class, healthy = "Soft 404", false
+	}
+
+	if c.Redirects != 0 {
+		redirect := "Redirect (" + strconv.Itoa(c.RedirectStatus) + ")"
+		if healthy {
+			return redirect + " to " + c.FinalURL, true
+		}
+		return redirect + " to " + class, false
+	}
+
+	return class, healthy
+}
+
+// statusClass returns the class of the status code.
+func statusClass(status int) string {
+	switch {
+	case status >= 200 && status <= 299:
+		return "OK (" + strconv.Itoa(status) + ")"
+	case status == 404:
+		return "Not Found (404)"
+	case status == 410:
+		return "Gone (410)"
+	case status >= 500:
+		return "Server Error (" + strconv.Itoa(status) + ")"
+	default:
+		return "Client Error (" + strconv.Itoa(status) + ")"
+	}
+}
+
+// isSoftNotFound tells whether the live page looks like a 404 page.
+func isSoftNotFound(original string, c models.URLCheck) bool {
+	if softNotFoundTitle.MatchString(c.Title) {
+		return true
+	}
+
+	// A deep URL that is redirected to the home page.
+	o, err1 := neturl.Parse(original)
+	f, err2 := neturl.Parse(c.FinalURL)
+	if err1 == nil && err2 == nil && c.Redirects != 0 {
+		return (f.Path == "" || f.Path == "/") && !(o.Path == "" || o.Path == "/")
+	}
+
+	return false
+}
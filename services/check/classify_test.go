@@ -0,0 +1,58 @@
+package check
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	os.Setenv("CHECK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CHECK_ALLOW_PRIVATE")
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	ts.Config.Handler.(*http.ServeMux).HandleFunc("/soft", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Page Not Found | Zeo</title></head></html>`))
+	})
+	ts.Config.Handler.(*http.ServeMux).HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	ts.Config.Handler.(*http.ServeMux).HandleFunc("/deep/old-post", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusMovedPermanently)
+	})
+	ts.Config.Handler.(*http.ServeMux).HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+		}
+	})
+	ts.Config.Handler.(*http.ServeMux).HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	tests := []struct {
+		path    string
+		class   string
+		healthy bool
+	}{
+		{"/live", "OK (200)", true},
+		{"/old", "Redirect (301) to " + ts.URL + "/live", true},
+		{"/missing", "Not Found (404)", false},
+		{"/gone", "Gone (410)", false},
+		{"/down", "Server Error (502)", false},
+		{"/soft", "Soft 404", false},
+		{"/deep/old-post", "Redirect (301) to Soft 404", false},
+	}
+
+	c := NewChecker()
+	for _, test := range tests {
+		class, healthy := Classify(ts.URL+test.path, c.Check(context.Background(), ts.URL+test.path))
+		if !strings.EqualFold(class, test.class) || healthy != test.healthy {
+			t.Fatalf("Error: Classify issue for %s: %s %v", test.path, class, healthy)
+		}
+	}
+}
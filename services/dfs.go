@@ -2,7 +2,9 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -10,8 +12,7 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/zeoagency/carbon/helpers"
-	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/httpclient"
 )
 
 // countries keeps country codes for DataForSEO.
@@ -40,17 +41,19 @@ type dfsApiRequest struct {
 	Device    string `json:"device"`
 }
 
-// getResultFromDFSApi returns DFS API response for the given data.
-func getResultFromDFSApi(kws keywords, country, language string, serpLimit int) (map[string]*dfsApiResponse, int, error) {
+// getResultFromDFSApi looks up the values by using DFS API, a request is sent for each value concurrently.
+// Values that are not looked up before the context is done are failed with the error of the context.
+func getResultFromDFSApi(ctx context.Context, values []string, country, language string, serpLimit int) (map[string][]serpResult, map[string]error) {
 	// set country code.
 	c := make(map[string]string)
 	_ = json.Unmarshal([]byte(countries), &c)
 	cCode := c[strings.ToUpper(country)]
-	// a map to keep result
-	responses := make(map[string]*dfsApiResponse) // the key is originalURL
+	// maps to keep results and errors, the key is the value.
+	results, errs := make(map[string][]serpResult), make(map[string]error)
 
+	mu := new(sync.Mutex)
 	wg := new(sync.WaitGroup)
-	for _, kw := range kws.ToStringSlice() {
+	for _, kw := range values {
 		rq := []dfsApiRequest{}
 		rq = append(rq, dfsApiRequest{
 			Keyword:   kw,
@@ -61,127 +64,78 @@ func getResultFromDFSApi(kws keywords, country, language string, serpLimit int)
 		})
 
 		wg.Add(1)
-		go sendRequest(wg, responses, kw, rq)
+		go func(kw string) {
+			defer wg.Done()
+
+			r, err := sendRequest(ctx, rq)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[kw] = err
+				return
+			}
+			results[kw] = r
+		}(kw)
 	}
 
 	wg.Wait()
-	return responses, http.StatusCreated, nil
+	return results, errs
 }
 
-// sendRequest works as async, adds all results to the given slice.
-func sendRequest(wg *sync.WaitGroup, responses map[string]*dfsApiResponse, key string, rq []dfsApiRequest) {
-	defer wg.Done()
-
+// sendRequest sends the request of a value, and returns its results.
+func sendRequest(ctx context.Context, rq []dfsApiRequest) ([]serpResult, error) {
 	rqJson, err := json.Marshal(rq)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	// Create the request.
-	req, err := http.NewRequest("POST", os.Getenv("DFS_API_ADDRESS"), bytes.NewReader(rqJson))
+	req, err := http.NewRequestWithContext(ctx, "POST", os.Getenv("DFS_API_ADDRESS"), bytes.NewReader(rqJson))
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	req.SetBasicAuth(os.Getenv("DFS_API_USER"), os.Getenv("DFS_API_PASSWORD"))
 
-	// Send the request.
-	c := &http.Client{}
-	res, err := c.Do(req)
+	// Send the request, transient errors are retried by the client.
+	res, err := httpclient.Default().Do(req)
 	if err != nil {
-		return
+		log.Printf("Error: Unavailable DFS API Service. %v\n", err)
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	// Check the result's status code.
 	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
 		log.Printf("Error: Unavailable DFS API Service. Status: %d\n", res.StatusCode)
-		return
+		return nil, fmt.Errorf("DFS API responded with %d status.", res.StatusCode)
 	}
 
 	// Read the result, unmarshal it to the struct.
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	response := dfsApiResponse{}
-	_ = json.Unmarshal(body, &response) // TODO: handle this error.
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Unexpected DFS API response. %w", err)
+	}
 
 	// Check the result's (body) status code.
 	if !(response.StatusCode >= 20000 && response.StatusCode <= 29999) {
 		log.Printf("Error: Unavailable DFS API Service. Status: %d\n", response.StatusCode)
-		return
+		return nil, fmt.Errorf("DFS API responded with %d status.", response.StatusCode)
 	}
 
-	responses[key] = &response
-}
-
-// parseDFSResponseToFieldsForURLs extract the response to the URLSet.
-// It only adds to success list when domains are matched.
-// If it couldn't find any related URLs, it adds to the fail list.
-func parseDFSResponseToFieldsForURLs(responses map[string]*dfsApiResponse, urlSet *models.URLSet) {
-	for _, url := range urlSet.URLs {
-		r := []string{}
-		if response := responses[url.String()]; response != nil {
-			for _, task := range response.Tasks {
-				for _, result := range task.Result {
-					for _, item := range result.Items {
-						if len(r) == 3 {
-							break
-						}
-
-						urlDomain, _, err := helpers.ExtractURL(item.URL)
-						if err != nil {
-							continue // The result's URL is not a valid URL.
-						}
-						if urlSet.URLs[result.Keyword].BaseURL == urlDomain {
-							r = append(r, item.URL)
-						}
-					}
-				}
-
+	r := []serpResult{}
+	for _, task := range response.Tasks {
+		for _, result := range task.Result {
+			for _, item := range result.Items {
+				r = append(r, serpResult{Title: item.Title, URL: item.URL, Description: item.Description})
 			}
 		}
-
-		if len(r) != 0 {
-			urlSet.AddSuccess(url.FullURL, r)
-			delete(urlSet.URLs, url.String())
-			delete(urlSet.Fails, url.String()) // remove from fail list
-		} else {
-			urlSet.AddFail(url.FullURL, "We could not find any related URLs.")
-		}
-	}
-}
-
-// parseDFSResponseToFieldsForKeywords extract the response to the KeywordSet.
-// It only adds to success list when the value is valid.
-// If it couldn't find any results, it adds to the fail list.
-func parseDFSResponseToFieldsForKeywords(responses map[string]*dfsApiResponse, keywordSet *models.KeywordSet) {
-	for keyword, _ := range keywordSet.Keywords {
-		r := []models.KeywordSuccessResult{}
-		if response := responses[keyword]; response != nil {
-			for _, task := range response.Tasks {
-				for _, result := range task.Result {
-					for _, item := range result.Items {
-						if len(r) == 10 {
-							break
-						}
-						r = append(r, models.KeywordSuccessResult{
-							Title: item.Title,
-							Desc:  item.Description,
-							URL:   item.URL,
-						})
-					}
-				}
-			}
-		}
-		if len(r) != 0 {
-			keywordSet.AddSuccess(keyword, r)
-			delete(keywordSet.Keywords, keyword)
-			delete(keywordSet.Fails, keyword) // remove from fail list
-		} else {
-			keywordSet.AddFail(keyword, "We could not find any related URLs.")
-		}
 	}
+	return r, nil
 }
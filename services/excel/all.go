@@ -0,0 +1,67 @@
+package excel
+
+import (
+	"fmt"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+// Statuses that are used in the all sheet.
+const (
+	statusSuccess = "success"
+	statusFail    = "fail"
+	statusSkipped = "skipped"
+)
+
+// StatusColors keeps the font and fill colors of the statuses in the all sheet.
+var StatusColors = map[string][2]string{
+	statusSuccess: {"#1e6b20", "#d5eb81"},
+	statusFail:    {"#9c0006", "#ffc7ce"},
+	statusSkipped: {"#595959", "#e7e6e6"},
+}
+
+// allRecordsForURLs returns rows of the all sheet.
+// It lists every input in the input order with its status, so the sheet can be sent by itself.
+func allRecordsForURLs(urlSet *models.URLSet) (int, records) {
+	order := urlSet.InputOrder()
+	return len(order), func(write func(record) error) error {
+		for _, originalURL := range order {
+			r := record{"url": originalURL}
+			if success, ok := urlSet.Successes[originalURL]; ok {
+				r["status"], r["suggested"] = statusSuccess, success.SuggestedURL
+				for i, url := range success.URLs {
+					r[fmt.Sprintf("alternative_%d", i+1)] = url
+				}
+			} else if skip, ok := urlSet.Skips[originalURL]; ok {
+				r["status"], r["reason"] = statusSkipped, skip.Reason
+			} else {
+				r["status"], r["reason"] = statusFail, urlSet.Fails[originalURL].Reason
+			}
+			setOptionalFields(r, urlSet, originalURL)
+
+			err := write(r)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// setStatusFormats colors the statuses in the given area.
+func setStatusFormats(f *excelize.File, sheet, area string) error {
+	for _, status := range []string{statusSuccess, statusFail, statusSkipped} {
+		colors := StatusColors[status]
+		format, err := f.NewConditionalStyle(fmt.Sprintf(`{"font":{"color":"%s"}, "fill":{"type":"pattern", "color":["%s"], "pattern":1}}`, colors[0], colors[1]))
+		if err != nil {
+			return err
+		}
+		err = f.SetConditionalFormat(sheet, area, fmt.Sprintf(`[{"type":"cell","criteria":"==","format":%d,"value":"\"%s\""}]`, format, status))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
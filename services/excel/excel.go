@@ -2,7 +2,9 @@ package excel
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/360EntSecGroup-Skylar/excelize/v2"
 
@@ -10,25 +12,116 @@ import (
 	"github.com/zeoagency/carbon/models"
 )
 
-var titleStyle = `{"alignment":{"horizontal":"center", "vertical":"center"}, "font":{"size":12, "name":"Calibri", "bold":true, "color":"#ffffff"}, "fill":{"type":"pattern", "color":["#000000"], "pattern":1}}`
+// styles keeps style IDs of the workbook.
+// They are created only once for the file, creating a style per cell bloats the style table.
+type styles struct {
+	Title     int
+	Highlight int
+	Muted     int
+	Percent   int
 
-// ConvertURLResultToExcel creates a excel file by using the URLSet.
-func ConvertURLResultToExcel(urlSet *models.URLSet) (*bytes.Buffer, error) {
-	f := excelize.NewFile()
-	f.NewSheet("success")
-	f.NewSheet("fail")
-	f.DeleteSheet("Sheet1") // delete default sheet.
+	ids map[Style]int // column styles of the layout.
+}
 
-	err := createSuccessSheetForURLs(f, urlSet)
+// newStyles creates the common styles of the layout for the given excel.
+func newStyles(f *excelize.File, layout *Layout) (*styles, error) {
+	s := &styles{ids: make(map[Style]int)}
+	for _, style := range []struct {
+		id    *int
+		style Style
+	}{
+		{&s.Title, layout.Styles.Title},
+		{&s.Highlight, layout.Styles.Highlight},
+		{&s.Muted, layout.Styles.Muted},
+	} {
+		id, err := s.get(f, style.style)
+		if err != nil {
+			return nil, err
+		}
+		*style.id = id
+	}
+
+	id, err := f.NewStyle(percentStyle)
 	if err != nil {
 		return nil, err
 	}
+	s.Percent = id
 
-	err = createFailSheetForURLs(f, urlSet)
+	return s, nil
+}
+
+// mark returns the ID of the common style for the mark.
+func (s *styles) mark(m mark) int {
+	if m.Style == markMuted {
+		return s.Muted
+	}
+	return s.Highlight
+}
+
+// get returns the ID of the style, it is created if it doesn't exist already.
+func (s *styles) get(f *excelize.File, style Style) (int, error) {
+	if id, ok := s.ids[style]; ok {
+		return id, nil
+	}
+	id, err := f.NewStyle(style.format())
+	if err != nil {
+		return 0, err
+	}
+	s.ids[style] = id
+	return id, nil
+}
+
+// record is a row of a sheet, the key is the field name.
+// A value can be a mark to set a common style to the cell.
+type record map[string]interface{}
+
+// Common styles that are used by marks.
+const (
+	markHighlight = "highlight"
+	markMuted     = "muted"
+)
+
+// mark is a value with a common style of the layout, like the suggested alternative.
+type mark struct {
+	Value interface{}
+	Style string
+}
+
+// records calls the write function for each row of the sheet, in the order.
+type records func(write func(record) error) error
+
+// ConvertURLResultToExcel creates a excel file by using the URLSet and the default layout.
+func ConvertURLResultToExcel(ctx context.Context, urlSet *models.URLSet) (*bytes.Buffer, error) {
+	return ConvertURLResultToExcelWithLayout(ctx, urlSet, DefaultLayout())
+}
+
+// ConvertURLResultToExcelWithLayout creates a excel file by using the URLSet and the layout.
+// Sheets are written by using stream writers, so rows are not kept in memory as cells.
+// It stops with the error of the context if the context is done between sheets.
+func ConvertURLResultToExcelWithLayout(ctx context.Context, urlSet *models.URLSet, layout *Layout) (*bytes.Buffer, error) {
+	sheets := sheetsForURLs(layout, urlSet)
+	f, s, err := newFile(layout, sheets)
 	if err != nil {
 		return nil, err
 	}
 
+	withData := optionalFieldsForURLs(urlSet)
+	for _, sheet := range sheets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if sheet.Name == "summary" {
+			err = createSummarySheet(f, s, sheet.Title, summaryOfURLs(urlSet))
+		} else {
+			size, rows := recordsForURLs(sheet.Name, urlSet)
+			err = renderSheet(f, s, sheet, withData, size, rows)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	b, err := f.WriteToBuffer()
 	if err != nil {
 		return nil, err
@@ -37,21 +130,35 @@ func ConvertURLResultToExcel(urlSet *models.URLSet) (*bytes.Buffer, error) {
 	return b, nil
 }
 
-// ConvertKeywordResultToExcel creates a excel file by using the KeywordSet.
-func ConvertKeywordResultToExcel(keywordSet *models.KeywordSet) (*bytes.Buffer, error) {
-	f := excelize.NewFile()
-	f.NewSheet("success")
-	f.NewSheet("fail")
-	f.DeleteSheet("Sheet1") // delete default sheet.
+// ConvertKeywordResultToExcel creates a excel file by using the KeywordSet and the default layout.
+func ConvertKeywordResultToExcel(ctx context.Context, keywordSet *models.KeywordSet) (*bytes.Buffer, error) {
+	return ConvertKeywordResultToExcelWithLayout(ctx, keywordSet, DefaultLayout())
+}
 
-	err := createSuccessSheetForKeywords(f, keywordSet)
+// ConvertKeywordResultToExcelWithLayout creates a excel file by using the KeywordSet and the layout.
+// Sheets are written by using stream writers, so rows are not kept in memory as cells.
+// It stops with the error of the context if the context is done between sheets.
+func ConvertKeywordResultToExcelWithLayout(ctx context.Context, keywordSet *models.KeywordSet, layout *Layout) (*bytes.Buffer, error) {
+	f, s, err := newFile(layout, layout.Keyword)
 	if err != nil {
 		return nil, err
 	}
 
-	err = createFailSheetForKeywords(f, keywordSet)
-	if err != nil {
-		return nil, err
+	withData := optionalFieldsForKeywords(keywordSet)
+	for _, sheet := range layout.Keyword {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if sheet.Name == "summary" {
+			err = createSummarySheet(f, s, sheet.Title, summaryOfKeywords(keywordSet))
+		} else {
+			size, rows := recordsForKeywords(sheet.Name, keywordSet)
+			err = renderSheet(f, s, sheet, withData, size, rows)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	b, err := f.WriteToBuffer()
@@ -62,241 +169,391 @@ func ConvertKeywordResultToExcel(keywordSet *models.KeywordSet) (*bytes.Buffer,
 	return b, nil
 }
 
-// createSuccessSheetForURLs creates success sheet for the given excel.
-func createSuccessSheetForURLs(f *excelize.File, urlSet *models.URLSet) error {
-	letters := []string{"A", "B", "C", "D", "E"}
-	titles := []string{"URL", "Alternative 1", "Alternative 2", "Alternative 3", "Suggested"}
-	// NOTE: letters and titles sizes must be same!
+// sheetsForURLs returns sheets of the layout for the URLSet.
+// Skipped and warnings sheets are only added when they have data.
+func sheetsForURLs(layout *Layout, urlSet *models.URLSet) []Sheet {
+	r := []Sheet{}
+	for _, sheet := range layout.URL {
+		if sheet.Name == "skipped" && len(urlSet.Skips) == 0 || sheet.Name == "warnings" && len(urlSet.Warnings) == 0 {
+			continue
+		}
+		r = append(r, sheet)
+	}
+	return r
+}
 
-	// Set styles
-	err := f.SetColWidth("success", "A", "D", 40)
-	if err != nil {
-		return err
+// newFile creates a excel file with the sheets, the first one is active.
+func newFile(layout *Layout, sheets []Sheet) (*excelize.File, *styles, error) {
+	f := excelize.NewFile()
+	hasDefault := false
+	for _, sheet := range sheets {
+		f.NewSheet(sheet.Title)
+		hasDefault = hasDefault || sheet.Title == "Sheet1"
 	}
-	err = f.SetColWidth("success", "E", "E", 70)
-	if err != nil {
-		return err
+	if !hasDefault {
+		f.DeleteSheet("Sheet1") // delete default sheet.
 	}
-	style, err := f.NewStyle(titleStyle)
-	if err != nil {
-		return err
-	}
-	err = f.SetCellStyle("success", "A1", "E1", style)
+	f.SetActiveSheet(f.GetSheetIndex(sheets[0].Title))
+
+	s, err := newStyles(f, layout)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	// Set titles.
-	for i, letter := range letters {
-		err := f.SetCellValue("success", fmt.Sprintf("%s%d", letter, 1), titles[i])
-		if err != nil {
-			return err
-		}
+	return f, s, nil
+}
 
-	}
+// renderSheet writes the rows to the sheet by using the columns of the layout.
+// Optional columns are only written if the field is in withData.
+// The size is the count of the rows, it is used for filters and conditional formats.
+func renderSheet(f *excelize.File, s *styles, sheet Sheet, withData map[string]bool, size int, rows records) error {
+	columns := visibleColumns(sheet, withData)
 
-	count := 2
-	for originalURL, success := range urlSet.Successes {
-		err := f.SetCellValue("success", fmt.Sprintf("%s%d", letters[0], count), originalURL)
+	// Set styles
+	titles := []string{}
+	styleIDs := make([]int, len(columns))
+	for i, c := range columns {
+		titles = append(titles, c.Title)
+
+		letter, err := excelize.ColumnNumberToName(i + 1)
 		if err != nil {
 			return err
 		}
-		for i, url := range success.URLs {
-			err := f.SetCellValue("success", fmt.Sprintf("%s%d", letters[i+1], count), url)
+		if c.Width != 0 {
+			err = f.SetColWidth(sheet.Title, letter, letter, c.Width)
 			if err != nil {
 				return err
 			}
-			err = f.SetCellValue("success", fmt.Sprintf("%s%d", letters[len(letters)-1], count), success.SuggestedURL)
+		}
+		if c.Style != nil {
+			styleIDs[i], err = s.get(f, *c.Style)
 			if err != nil {
 				return err
 			}
-			index, _ := helpers.StringSliceContains(success.URLs, success.SuggestedURL)
-			if index != -1 {
-				style, err := f.NewStyle(`{"font":{"bold":true}}`)
-				if err != nil {
-					return err
-				}
-				f.SetCellStyle("success", fmt.Sprintf("%s%d", letters[index+1], count), fmt.Sprintf("%s%d", letters[index+1], count), style)
+		}
+		if c.Field == "status" && size != 0 {
+			err = setStatusFormats(f, sheet.Title, fmt.Sprintf("%s2:%s%d", letter, letter, size+1))
+			if err != nil {
+				return err
 			}
 		}
-		count++
 	}
 
-	if count > 2 {
-		style, err = f.NewStyle(`{"fill":{"type":"pattern", "color":["#d5eb81"], "pattern":1}}`)
+	if sheet.Freeze {
+		err := f.SetPanes(sheet.Title, `{"freeze":true,"split":false,"x_split":0,"y_split":1,"top_left_cell":"A2","active_pane":"bottomLeft","panes":[{"sqref":"A2","active_cell":"A2","pane":"bottomLeft"}]}`)
 		if err != nil {
 			return err
 		}
-		err = f.SetCellStyle("success", "E2", fmt.Sprintf("E%d", count-1), style)
+	}
+
+	if sheet.Filter && len(columns) != 0 {
+		lastLetter, err := excelize.ColumnNumberToName(len(columns))
 		if err != nil {
 			return err
 		}
-	}
-
-	return nil
-}
-
-// createFailSheetForURLs creates fail sheet for the given excel.
-func createFailSheetForURLs(f *excelize.File, urlSet *models.URLSet) error {
-	letters := []string{"A", "B"}
-	titles := []string{"URL", "Reason"}
-	// NOTE: letters and titles sizes must be same!
-
-	// Set titles.
-	for i, letter := range letters {
-		err := f.SetCellValue("fail", fmt.Sprintf("%s%d", letter, 1), titles[i])
+		err = f.AutoFilter(sheet.Title, "A1", fmt.Sprintf("%s%d", lastLetter, size+1), "{}")
 		if err != nil {
 			return err
 		}
 	}
 
-	// Set styles
-	err := f.SetColWidth("fail", "A", "B", 40)
-	if err != nil {
-		return err
-	}
-	style, err := f.NewStyle(titleStyle)
-	if err != nil {
-		return err
-	}
-	err = f.SetCellStyle("fail", "A1", "B1", style)
+	sw, err := newStreamWriter(f, s, sheet.Title, titles)
 	if err != nil {
 		return err
 	}
 
 	count := 2
-	for originalURL, fail := range urlSet.Fails {
-		err := f.SetCellValue("fail", fmt.Sprintf("%s%d", "A", count), originalURL)
-		if err != nil {
-			return err
-		}
-		err = f.SetCellValue("fail", fmt.Sprintf("%s%d", "B", count), fail.Reason)
-		if err != nil {
-			return err
+	err = rows(func(r record) error {
+		values := make([]interface{}, len(columns))
+		for i, c := range columns {
+			v := r[c.Field]
+			if m, ok := v.(mark); ok {
+				v = excelize.Cell{StyleID: s.mark(m), Value: m.Value}
+			} else if v != nil && c.Style != nil {
+				v = excelize.Cell{StyleID: styleIDs[i], Value: v}
+			}
+			values[i] = v
 		}
+
+		err := sw.SetRow(fmt.Sprintf("A%d", count), values)
 		count++
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return sw.Flush()
 }
 
-// createSuccessSheetForKeywords creates success sheet for the given excel.
-func createSuccessSheetForKeywords(f *excelize.File, keywordSet *models.KeywordSet) error {
-	letters := []string{"A", "B", "C", "D", "E"}
-	titles := []string{"Keyword", "Position", "Title", "URL", "Description"}
-	// NOTE: letters and titles sizes must be same!
-
-	// Set titles.
-	for i, letter := range letters {
-		err := f.SetCellValue("success", fmt.Sprintf("%s%d", letter, 1), titles[i])
-		if err != nil {
-			return err
+// visibleColumns returns columns of the sheet, optional ones are only returned if the field is in withData.
+func visibleColumns(sheet Sheet, withData map[string]bool) []Column {
+	r := []Column{}
+	for _, c := range sheet.Columns {
+		if c.Optional && !withData[c.Field] {
+			continue
 		}
+		r = append(r, c)
 	}
+	return r
+}
 
-	// Set styles
-	err := f.SetColWidth("success", "A", "A", 40)
-	_ = f.SetColWidth("success", "C", "D", 40)
-	_ = f.SetColWidth("success", "E", "E", 110)
+// newStreamWriter creates a stream writer for the sheet, and writes the titles as the first row.
+// NOTE: Column widths, panes, filters and conditional formats must be set before,
+// the stream writer takes them from the sheet while it is created.
+func newStreamWriter(f *excelize.File, s *styles, sheet string, titles []string) (*excelize.StreamWriter, error) {
+	sw, err := f.NewStreamWriter(sheet)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	style, err := f.NewStyle(titleStyle)
-	if err != nil {
-		return err
+
+	row := []interface{}{}
+	for _, title := range titles {
+		row = append(row, excelize.Cell{StyleID: s.Title, Value: title})
 	}
-	err = f.SetCellStyle("success", "A1", "E1", style)
+	err = sw.SetRow("A1", row)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	count := 2
-	for keyword, success := range keywordSet.Successes {
-		err := f.SetCellValue("success", fmt.Sprintf("%s%d", letters[0], count), keyword)
-		if err != nil {
-			return err
-		}
-		groupCount := 0
-		for i, result := range success.Results {
-			if groupCount != 0 {
-				location := fmt.Sprintf("%s%d", letters[0], count)
-				err := f.SetCellValue("success", location, keyword)
-				if err != nil {
-					return err
-				}
-				style, err := f.NewStyle(`{"font":{"color":"#cccccc"}}`)
-				if err != nil {
-					return err
-				}
-				err = f.SetCellStyle("success", location, location, style)
-				if err != nil {
-					return err
-				}
-			}
-			err = f.SetCellValue("success", fmt.Sprintf("%s%d", letters[1], count), fmt.Sprintf("#%d", i+1))
+	return sw, nil
+}
+
+// successRecordsForURLs returns rows of the success sheet.
+// The alternative that is suggested is highlighted.
+func successRecordsForURLs(urlSet *models.URLSet) (int, records) {
+	order := urlSet.SuccessOrder()
+	return len(order), func(write func(record) error) error {
+		for _, originalURL := range order {
+			success := urlSet.Successes[originalURL]
+			r := record{"url": originalURL, "suggested": success.SuggestedURL}
+			setAlternatives(r, success.URLs, success.SuggestedURL)
+			setOptionalFields(r, urlSet, originalURL)
+
+			err := write(r)
 			if err != nil {
 				return err
 			}
-			err = f.SetCellValue("success", fmt.Sprintf("%s%d", letters[2], count), result.Title)
+		}
+		return nil
+	}
+}
+
+// failRecordsForURLs returns rows of the fail sheet.
+func failRecordsForURLs(urlSet *models.URLSet) (int, records) {
+	order := urlSet.FailOrder()
+	return len(order), func(write func(record) error) error {
+		for _, originalURL := range order {
+			r := record{"url": originalURL, "reason": urlSet.Fails[originalURL].Reason}
+			setOptionalFields(r, urlSet, originalURL)
+
+			err := write(r)
 			if err != nil {
 				return err
 			}
-			err = f.SetCellValue("success", fmt.Sprintf("%s%d", letters[3], count), result.URL)
+		}
+		return nil
+	}
+}
+
+// skipRecordsForURLs returns rows of the skipped sheet.
+// It lists pre-checked URLs that are not broken, so they are not looked up.
+func skipRecordsForURLs(urlSet *models.URLSet) (int, records) {
+	order := urlSet.SkipOrder()
+	return len(order), func(write func(record) error) error {
+		for _, originalURL := range order {
+			r := record{"url": originalURL, "reason": urlSet.Skips[originalURL].Reason}
+			setOptionalFields(r, urlSet, originalURL)
+
+			err := write(r)
 			if err != nil {
 				return err
 			}
-			err = f.SetCellValue("success", fmt.Sprintf("%s%d", letters[4], count), result.Desc)
+		}
+		return nil
+	}
+}
+
+// warningRecordsForURLs returns rows of the warnings sheet.
+// It lists redirect chains, loops and dead ends in the suggestions.
+func warningRecordsForURLs(urlSet *models.URLSet) (int, records) {
+	return len(urlSet.Warnings), func(write func(record) error) error {
+		for _, warning := range urlSet.Warnings {
+			err := write(record{"url": warning.URL, "warning": warning.Type, "detail": warning.Detail})
 			if err != nil {
 				return err
 			}
-			count++
-			groupCount++
 		}
+		return nil
+	}
+}
+
+// recordsForURLs returns the count of rows and the rows of the sheet.
+func recordsForURLs(name string, urlSet *models.URLSet) (int, records) {
+	switch name {
+	case "all":
+		return allRecordsForURLs(urlSet)
+	case "success":
+		return successRecordsForURLs(urlSet)
+	case "fail":
+		return failRecordsForURLs(urlSet)
+	case "skipped":
+		return skipRecordsForURLs(urlSet)
+	case "warnings":
+		return warningRecordsForURLs(urlSet)
+	}
+	return 0, noRecords
+}
+
+// recordsForKeywords returns the count of rows and the rows of the sheet.
+func recordsForKeywords(name string, keywordSet *models.KeywordSet) (int, records) {
+	switch name {
+	case "success":
+		return successRecordsForKeywords(keywordSet)
+	case "fail":
+		return failRecordsForKeywords(keywordSet)
 	}
+	return 0, noRecords
+}
 
+// noRecords is used for sheets that don't have rows.
+func noRecords(write func(record) error) error {
 	return nil
 }
 
-// createFailSheetForKeywords creates fail sheet for the given excel.
-func createFailSheetForKeywords(f *excelize.File, keywordSet *models.KeywordSet) error {
-	letters := []string{"A", "B"}
-	titles := []string{"Keyword", "Reason"}
-	// NOTE: letters and titles sizes must be same!
+// setAlternatives sets alternative fields of the record, the suggested one is highlighted.
+func setAlternatives(r record, urls []string, suggestedURL string) {
+	index, _ := helpers.StringSliceContains(urls, suggestedURL)
+	for i, url := range urls {
+		field := fmt.Sprintf("alternative_%d", i+1)
+		if i == index {
+			r[field] = mark{Value: url, Style: markHighlight}
+		} else {
+			r[field] = url
+		}
+	}
+}
 
-	// Set titles.
-	for i, letter := range letters {
-		err := f.SetCellValue("fail", fmt.Sprintf("%s%d", letter, 1), titles[i])
-		if err != nil {
-			return err
+// optionalFieldsForURLs returns optional fields that have data in the URLSet.
+func optionalFieldsForURLs(urlSet *models.URLSet) map[string]bool {
+	r := map[string]bool{
+		"input_status": len(urlSet.Classes) != 0,
+		"duplicates":   len(urlSet.Duplicates) != 0,
+		"hits":         len(urlSet.Hits) != 0,
+		"attempts":     len(urlSet.Attempts) != 0,
+	}
+	for _, success := range urlSet.Successes {
+		r["verification"] = r["verification"] || success.Checks != nil
+		r["provider"] = r["provider"] || success.Provider != ""
+	}
+	return r
+}
+
+// setOptionalFields sets optional fields of the record, if the url has data for them.
+func setOptionalFields(r record, urlSet *models.URLSet, originalURL string) {
+	if v, ok := urlSet.Classes[originalURL]; ok {
+		r["input_status"] = v
+	}
+	if v, ok := urlSet.Duplicates[originalURL]; ok {
+		r["duplicates"] = v
+	}
+	if v, ok := urlSet.Hits[originalURL]; ok {
+		r["hits"] = v
+	}
+	if success, ok := urlSet.Successes[originalURL]; ok && success.Checks != nil {
+		lines := []string{}
+		for i, url := range success.URLs {
+			lines = append(lines, fmt.Sprintf("Alternative %d: %s", i+1, success.Checks[url]))
 		}
+		r["verification"] = strings.Join(lines, "\n")
 	}
+	if success, ok := urlSet.Successes[originalURL]; ok && success.Provider != "" {
+		r["provider"] = success.Provider
+	}
+	if attempts, ok := urlSet.Attempts[originalURL]; ok {
+		r["attempts"] = attemptsOf(attempts)
+	}
+}
 
-	// Set styles
-	err := f.SetColWidth("fail", "A", "B", 40)
-	if err != nil {
-		return err
+// attemptsOf returns the attempts as lines, like "SerpApi: no result".
+func attemptsOf(attempts []models.Attempt) string {
+	lines := []string{}
+	for _, attempt := range attempts {
+		lines = append(lines, attempt.String())
 	}
-	style, err := f.NewStyle(titleStyle)
-	if err != nil {
-		return err
+	return strings.Join(lines, "\n")
+}
+
+// optionalFieldsForKeywords returns optional fields that have data in the KeywordSet.
+func optionalFieldsForKeywords(keywordSet *models.KeywordSet) map[string]bool {
+	r := map[string]bool{
+		"duplicates": len(keywordSet.Duplicates) != 0,
+		"attempts":   len(keywordSet.Attempts) != 0,
 	}
-	err = f.SetCellStyle("fail", "A1", "B1", style)
-	if err != nil {
-		return err
+	for _, success := range keywordSet.Successes {
+		r["provider"] = r["provider"] || success.Provider != ""
 	}
+	return r
+}
 
-	count := 2
-	for keyword, fail := range keywordSet.Fails {
-		err := f.SetCellValue("fail", fmt.Sprintf("%s%d", "A", count), keyword)
-		if err != nil {
-			return err
-		}
-		err = f.SetCellValue("fail", fmt.Sprintf("%s%d", "B", count), fail.Reason)
-		if err != nil {
-			return err
+// successRecordsForKeywords returns rows of the success sheet.
+// Each result is a row, keywords are repeated as muted for the rows of the same group.
+func successRecordsForKeywords(keywordSet *models.KeywordSet) (int, records) {
+	order := keywordSet.SuccessOrder()
+	size := 0
+	for _, keyword := range order {
+		size += len(keywordSet.Successes[keyword].Results)
+	}
+
+	return size, func(write func(record) error) error {
+		for _, keyword := range order {
+			for i, result := range keywordSet.Successes[keyword].Results {
+				r := record{
+					"keyword":     keyword,
+					"position":    fmt.Sprintf("#%d", i+1),
+					"title":       result.Title,
+					"url":         result.URL,
+					"description": result.Desc,
+				}
+				if i != 0 {
+					r["keyword"] = mark{Value: keyword, Style: markMuted}
+				} else {
+					if duplicates, ok := keywordSet.Duplicates[keyword]; ok {
+						r["duplicates"] = duplicates
+					}
+					if provider := keywordSet.Successes[keyword].Provider; provider != "" {
+						r["provider"] = provider
+					}
+				}
+
+				err := write(r)
+				if err != nil {
+					return err
+				}
+			}
 		}
-		count++
+		return nil
 	}
+}
 
-	return nil
+// failRecordsForKeywords returns rows of the fail sheet.
+func failRecordsForKeywords(keywordSet *models.KeywordSet) (int, records) {
+	order := keywordSet.FailOrder()
+	return len(order), func(write func(record) error) error {
+		for _, keyword := range order {
+			r := record{"keyword": keyword, "reason": keywordSet.Fails[keyword].Reason}
+			if duplicates, ok := keywordSet.Duplicates[keyword]; ok {
+				r["duplicates"] = duplicates
+			}
+			if attempts, ok := keywordSet.Attempts[keyword]; ok {
+				r["attempts"] = attemptsOf(attempts)
+			}
+
+			err := write(r)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 }
@@ -1,6 +1,7 @@
 package excel
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"testing"
@@ -27,12 +28,12 @@ func TestConvertURLResultToExcel(t *testing.T) {
 		"https://googlebunubulamaz.com/",
 		"notaavalidurl",
 	)
-	_, err := services.GetResultByUsingURLs(urlSet, "tr", "tr")
+	_, err := services.GetResultByUsingURLs(context.Background(), urlSet, "tr", "tr")
 	if err != nil {
 		t.Fatal("Error occur while getting the result:", err)
 	}
 
-	f, err := ConvertURLResultToExcel(urlSet)
+	f, err := ConvertURLResultToExcel(context.Background(), urlSet)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,12 +63,12 @@ func TestConvertKeywordResultToExcel(t *testing.T) {
 		"boratanrikulu blog postgresql nedir",
 		"googlebunubulamaz blog",
 	)
-	_, err := services.GetResultByUsingKeywords(keywordSet, "tr", "tr")
+	_, err := services.GetResultByUsingKeywords(context.Background(), keywordSet, "tr", "tr")
 	if err != nil {
 		t.Fatal("Error occur while getting the result:", err)
 	}
 
-	f, err := ConvertKeywordResultToExcel(keywordSet)
+	f, err := ConvertKeywordResultToExcel(context.Background(), keywordSet)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,3 +107,227 @@ func printSheet(f *excelize.File, sheet string) error {
 
 	return nil
 }
+
+func TestConvertURLResultToExcelShouldKeepOrder(t *testing.T) {
+	urlSet := models.NewURLSet()
+	urlSet.Add(
+		"https://boratanrikulu.dev/c",
+		"https://boratanrikulu.dev/a",
+		"https://boratanrikulu.dev/b",
+		"https://boratanrikulu.dev/a",
+	)
+	for _, u := range urlSet.Order {
+		urlSet.AddSuccess(u, []string{u + "-new"})
+	}
+
+	f, err := ConvertURLResultToExcel(context.Background(), urlSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eF, err := excelize.OpenReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := eF.GetRows("success")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, u := range []string{"https://boratanrikulu.dev/c", "https://boratanrikulu.dev/a", "https://boratanrikulu.dev/b"} {
+		if rows[i+1][0] != u {
+			t.Fatalf("Error: Row %d must be %s, not %s.", i+1, u, rows[i+1][0])
+		}
+	}
+	if rows[0][5] != "Duplicates" || rows[2][5] != "1" {
+		t.Fatal("Error: Duplicates column issue.", rows)
+	}
+}
+
+func TestConvertURLResultToExcelShouldHaveAllSheet(t *testing.T) {
+	urlSet := models.NewURLSet()
+	urlSet.Add(
+		"https://boratanrikulu.dev/a",
+		"notaavalidurl",
+		"https://boratanrikulu.dev/b",
+	)
+	urlSet.AddSuccess("https://boratanrikulu.dev/a", []string{"https://boratanrikulu.dev/a-new"})
+	urlSet.AddSkip("https://boratanrikulu.dev/b", "OK (200)")
+
+	f, err := ConvertURLResultToExcel(context.Background(), urlSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eF, err := excelize.OpenReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := eF.GetRows("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"https://boratanrikulu.dev/a", "success"},
+		{"notaavalidurl", "fail"},
+		{"https://boratanrikulu.dev/b", "skipped"},
+	}
+	for i, e := range expected {
+		if rows[i+1][0] != e[0] || rows[i+1][1] != e[1] {
+			t.Fatalf("Error: Row %d must be %v, not %v.", i+1, e, rows[i+1])
+		}
+	}
+	if rows[1][3] != "https://boratanrikulu.dev/a-new" || rows[2][2] != "That's not an URL." || rows[3][2] != "OK (200)" {
+		t.Fatal("Error: All sheet values issue.", rows)
+	}
+}
+
+func TestConvertKeywordResultToExcelShouldHaveSummary(t *testing.T) {
+	keywordSet := models.NewKeywordSet()
+	keywordSet.Meta.Country, keywordSet.Meta.Language = "tr", "tr"
+	keywordSet.Meta.AddProvider("SerpApi")
+	keywordSet.Add("a", "b", "c", "d")
+	keywordSet.AddSuccess("a", []models.KeywordSuccessResult{
+		{URL: "https://www.boratanrikulu.dev/a"},
+		{URL: "https://zeo.org/a"},
+	})
+	keywordSet.AddSuccess("b", []models.KeywordSuccessResult{
+		{URL: "https://boratanrikulu.dev/b"},
+	})
+	keywordSet.AddFail("c", "No result.")
+	keywordSet.AddFail("d", "No result.")
+
+	f, err := ConvertKeywordResultToExcel(context.Background(), keywordSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eF, err := excelize.OpenReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eF.GetSheetName(eF.GetActiveSheetIndex()) != "summary" {
+		t.Fatal("Error: The summary sheet must be active.")
+	}
+	rows, err := eF.GetRows("summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string][]string{}
+	for _, row := range rows {
+		if len(row) > 1 {
+			values[row[0]] = row[1:]
+		}
+	}
+	if values["Type"][0] != "keyword" || values["Country"][0] != "tr" || values["Providers"][0] != "SerpApi" {
+		t.Fatal("Error: Summary metadata issue.", rows)
+	}
+	if values["success"][0] != "2" || values["success"][1] != "50.00%" {
+		t.Fatal("Error: Summary status issue.", values["success"])
+	}
+	if values["No result."][0] != "2" {
+		t.Fatal("Error: Summary reason issue.", values["No result."])
+	}
+	if values["boratanrikulu.dev"][0] != "2" || values["zeo.org"][0] != "1" {
+		t.Fatal("Error: Summary domain issue.", rows)
+	}
+}
+
+func TestURLReportShouldReadRowsWhileTheyAreWritten(t *testing.T) {
+	urlSet := largeURLSet(8)
+	r := URLReport(urlSet, DefaultLayout())
+
+	// Rows are created from the result when they are read, so a change after the first row is in the next ones.
+	changed := urlSet.Order[4]
+	read, found := 0, false
+	err := r.Tables[0].EachRow(func(values []Value) error {
+		read++
+		if read == 1 {
+			urlSet.AddSuccess(changed, []string{"https://zeo.org/changed"})
+		}
+		if values[0].Text == changed {
+			for _, v := range values {
+				found = found || v.Text == "https://zeo.org/changed"
+			}
+		}
+		return nil
+	})
+	if err != nil || read != 8 {
+		t.Fatal("Error: All rows must be read.", read, err)
+	}
+	if !found {
+		t.Fatal("Error: Rows must not be created before they are read.")
+	}
+
+	// The first error stops reading, the rest of the rows are not created.
+	read = 0
+	err = r.Tables[0].EachRow(func(values []Value) error {
+		read++
+		return fmt.Errorf("stop")
+	})
+	if err == nil || read != 1 {
+		t.Fatal("Error: Reading must stop at the first error.", read, err)
+	}
+}
+
+func BenchmarkConvertURLResultToExcel(b *testing.B) {
+	for _, size := range []int{10000, 100000} {
+		b.Run(fmt.Sprintf("%d rows", size), func(b *testing.B) {
+			urlSet := largeURLSet(size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := ConvertURLResultToExcel(context.Background(), urlSet)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConvertKeywordResultToExcel(b *testing.B) {
+	for _, size := range []int{10000, 100000} {
+		b.Run(fmt.Sprintf("%d rows", size), func(b *testing.B) {
+			keywordSet := models.NewKeywordSet()
+			for i := 0; i < size/10; i++ {
+				keyword := fmt.Sprintf("boratanrikulu blog %d", i)
+				keywordSet.Add(keyword)
+				results := []models.KeywordSuccessResult{}
+				for j := 0; j < 10; j++ {
+					results = append(results, models.KeywordSuccessResult{
+						Title: fmt.Sprintf("Title %d", j),
+						URL:   fmt.Sprintf("https://site-%d.com/%d", j, i),
+						Desc:  "Lorem ipsum dolor sit amet, consectetur adipiscing elit.",
+					})
+				}
+				keywordSet.AddSuccess(keyword, results)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := ConvertKeywordResultToExcel(context.Background(), keywordSet)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// largeURLSet returns a URLSet of the size, a quarter of the URLs are failed.
+func largeURLSet(size int) *models.URLSet {
+	urlSet := models.NewURLSet()
+	for i := 0; i < size; i++ {
+		urlSet.Add(fmt.Sprintf("https://boratanrikulu.dev/post-%d", i))
+	}
+	for i, u := range urlSet.Order {
+		if i%4 == 0 {
+			urlSet.AddFail(u, "We could not find any related URLs.")
+			continue
+		}
+		urlSet.AddSuccess(u, []string{u + "-a", u + "-b", u + "-c"})
+	}
+	return urlSet
+}
@@ -0,0 +1,323 @@
+package excel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrLayoutNotFound is returned when there is no layout with the given name.
+var ErrLayoutNotFound = errors.New("Layout is not found.")
+
+// layoutName is used to validate layout names, they are used as file names.
+var layoutName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// sheetFields keeps the fields that can be used as columns.
+// The first key is the type ("url" or "keyword"), the second one is the sheet name.
+var sheetFields = map[string]map[string][]string{
+	"url": {
+		"summary":  {},
+		"all":      {"url", "status", "reason", "alternative_1", "alternative_2", "alternative_3", "suggested", "input_status", "duplicates", "hits", "verification", "provider", "attempts"},
+		"success":  {"url", "alternative_1", "alternative_2", "alternative_3", "suggested", "input_status", "duplicates", "hits", "verification", "provider"},
+		"fail":     {"url", "reason", "input_status", "duplicates", "hits", "verification", "attempts"},
+		"skipped":  {"url", "reason", "input_status", "duplicates", "hits", "verification"},
+		"warnings": {"url", "warning", "detail"},
+	},
+	"keyword": {
+		"summary": {},
+		"success": {"keyword", "position", "title", "url", "description", "duplicates", "provider"},
+		"fail":    {"keyword", "reason", "duplicates", "attempts"},
+	},
+}
+
+// hexColor is used to validate colors of the brand.
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// logoTypes keeps the supported logo extensions with their MIME types.
+var logoTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// Layout describes the workbook; its sheets, columns, titles, widths and styles.
+// Layouts are defined as YAML or JSON, see defaultLayout for an example.
+type Layout struct {
+	Name    string  `yaml:"name"`
+	Brand   Brand   `yaml:"brand"`
+	Styles  Styles  `yaml:"styles"`
+	URL     []Sheet `yaml:"url"`     // sheets for "url" type, in the order.
+	Keyword []Sheet `yaml:"keyword"` // sheets for "keyword" type, in the order.
+}
+
+// Brand is used by HTML and PDF reports.
+type Brand struct {
+	Name  string `yaml:"name"`  // shown in the header of the report.
+	Logo  string `yaml:"logo"`  // a PNG or JPEG file, relative to LAYOUTS_PATH.
+	Color string `yaml:"color"` // header color, the fill of the title style is used if it is not set.
+}
+
+// Styles keeps the common styles of the workbook.
+type Styles struct {
+	Title     Style `yaml:"title"`     // header rows.
+	Highlight Style `yaml:"highlight"` // the suggested alternative and summary labels.
+	Muted     Style `yaml:"muted"`     // repeated keywords of the same group.
+}
+
+// Sheet describes a sheet of the workbook.
+// The name must be one of the keys of sheetFields, the title is the name that is shown.
+// Skipped and warnings sheets are only added when they have data.
+type Sheet struct {
+	Name    string   `yaml:"name"`
+	Title   string   `yaml:"title"`
+	Freeze  bool     `yaml:"freeze"` // freezes the header row.
+	Filter  bool     `yaml:"filter"` // adds auto filters to all columns.
+	Columns []Column `yaml:"columns"`
+}
+
+// Column describes a column of the sheet.
+type Column struct {
+	Field    string  `yaml:"field"`
+	Title    string  `yaml:"title"`
+	Width    float64 `yaml:"width"`
+	Optional bool    `yaml:"optional"` // the column is only added when the field has data.
+	Style    *Style  `yaml:"style"`
+}
+
+// Style is a cell style. Colors are hex codes like "#000000".
+type Style struct {
+	Font  string  `yaml:"font"`
+	Size  float64 `yaml:"size"`
+	Bold  bool    `yaml:"bold"`
+	Color string  `yaml:"color"`
+	Fill  string  `yaml:"fill"`
+	Align string  `yaml:"align"` // "left", "center" or "right".
+}
+
+// DefaultLayout returns the layout that is embedded to the binary.
+func DefaultLayout() *Layout {
+	l, err := parseLayout([]byte(defaultLayout), nil)
+	if err != nil {
+		panic(err) // The default layout is covered by the tests.
+	}
+	return l
+}
+
+// LoadLayout returns the layout that has the given name.
+// The default layout is returned if the name is empty or "default".
+// Others are read from LAYOUTS_PATH, like "<LAYOUTS_PATH>/<name>.yaml".
+// Supported extensions are ".yaml", ".yml" and ".json".
+func LoadLayout(name string) (*Layout, error) {
+	if name == "" || name == "default" {
+		return DefaultLayout(), nil
+	}
+	if !layoutName.MatchString(name) || os.Getenv("LAYOUTS_PATH") == "" {
+		return nil, ErrLayoutNotFound
+	}
+
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		b, err := ioutil.ReadFile(filepath.Join(os.Getenv("LAYOUTS_PATH"), name+ext))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		l, err := ParseLayout(b)
+		if err != nil {
+			return nil, err
+		}
+		if l.Name == "" {
+			l.Name = name
+		}
+		return l, nil
+	}
+
+	return nil, ErrLayoutNotFound
+}
+
+// ParseLayout parses the layout from YAML or JSON.
+// Missing parts are taken from the default layout;
+// styles, sheets of a type and columns of a sheet.
+func ParseLayout(b []byte) (*Layout, error) {
+	return parseLayout(b, DefaultLayout())
+}
+
+// parseLayout parses and validates the layout, missing parts are taken from the base if it is set.
+func parseLayout(b []byte, base *Layout) (*Layout, error) {
+	l := &Layout{}
+	err := yaml.Unmarshal(b, l) // YAML is a superset of JSON.
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the layout: %s", err)
+	}
+
+	if base != nil {
+		if l.Styles.Title == (Style{}) {
+			l.Styles.Title = base.Styles.Title
+		}
+		if l.Styles.Highlight == (Style{}) {
+			l.Styles.Highlight = base.Styles.Highlight
+		}
+		if l.Styles.Muted == (Style{}) {
+			l.Styles.Muted = base.Styles.Muted
+		}
+		if l.Brand == (Brand{}) {
+			l.Brand = base.Brand
+		}
+		if len(l.URL) == 0 {
+			l.URL = base.URL
+		}
+		if len(l.Keyword) == 0 {
+			l.Keyword = base.Keyword
+		}
+	}
+
+	if l.Brand.Color == "" {
+		l.Brand.Color = l.Styles.Title.Fill
+	}
+	if l.Brand.Color != "" && !hexColor.MatchString(l.Brand.Color) {
+		return nil, fmt.Errorf("Brand color \"%s\" must be a hex code like \"#000000\".", l.Brand.Color)
+	}
+	if l.Brand.Logo != "" {
+		if _, ok := logoTypes[strings.ToLower(filepath.Ext(l.Brand.Logo))]; !ok {
+			return nil, fmt.Errorf("Brand logo \"%s\" must be a PNG or JPEG file.", l.Brand.Logo)
+		}
+	}
+
+	err = l.validate("url", l.URL, base)
+	if err != nil {
+		return nil, err
+	}
+	err = l.validate("keyword", l.Keyword, base)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// validate checks sheets and columns of the type, and sets default values.
+// Columns of the sheet are taken from the base if they are not set.
+func (l *Layout) validate(t string, sheets []Sheet, base *Layout) error {
+	if len(sheets) == 0 {
+		return fmt.Errorf("Layout doesn't have any sheets for \"%s\" type.", t)
+	}
+
+	titles := make(map[string]bool)
+	for i := range sheets {
+		sheet := &sheets[i]
+		fields, ok := sheetFields[t][sheet.Name]
+		if !ok {
+			return fmt.Errorf("Sheet \"%s\" is not supported for \"%s\" type.", sheet.Name, t)
+		}
+		if sheet.Title == "" {
+			sheet.Title = sheet.Name
+		}
+		if len(sheet.Title) > 31 {
+			return fmt.Errorf("Sheet title \"%s\" is longer than 31 characters.", sheet.Title)
+		}
+		if titles[sheet.Title] {
+			return fmt.Errorf("Sheet title \"%s\" is used more than once.", sheet.Title)
+		}
+		titles[sheet.Title] = true
+
+		if len(sheet.Columns) == 0 && base != nil {
+			sheet.Columns = base.columns(t, sheet.Name)
+		}
+		if len(sheet.Columns) == 0 && len(fields) != 0 {
+			return fmt.Errorf("Sheet \"%s\" doesn't have any columns.", sheet.Name)
+		}
+		for j := range sheet.Columns {
+			column := &sheet.Columns[j]
+			if !contains(fields, column.Field) {
+				return fmt.Errorf("Field \"%s\" is not supported for \"%s\" sheet.", column.Field, sheet.Name)
+			}
+			if column.Title == "" {
+				column.Title = column.Field
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadLogo reads the logo of the brand, it returns the content and the MIME type.
+// The content is nil if the brand doesn't have a logo.
+func (b Brand) ReadLogo() ([]byte, string, error) {
+	if b.Logo == "" {
+		return nil, "", nil
+	}
+	path := b.Logo
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(os.Getenv("LAYOUTS_PATH"), path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("Unable to read the logo: %s", err)
+	}
+	return content, logoTypes[strings.ToLower(filepath.Ext(path))], nil
+}
+
+// columns returns a copy of the columns of the sheet.
+func (l *Layout) columns(t, name string) []Column {
+	sheets := l.URL
+	if t == "keyword" {
+		sheets = l.Keyword
+	}
+	for _, sheet := range sheets {
+		if sheet.Name == name {
+			return append([]Column{}, sheet.Columns...)
+		}
+	}
+	return nil
+}
+
+// format converts the style to excelize's style format.
+func (s Style) format() string {
+	style := make(map[string]interface{})
+
+	font := make(map[string]interface{})
+	if s.Font != "" {
+		font["name"] = s.Font
+	}
+	if s.Size != 0 {
+		font["size"] = s.Size
+	}
+	if s.Bold {
+		font["bold"] = true
+	}
+	if s.Color != "" {
+		font["color"] = s.Color
+	}
+	if len(font) != 0 {
+		style["font"] = font
+	}
+	if s.Fill != "" {
+		style["fill"] = map[string]interface{}{"type": "pattern", "color": []string{s.Fill}, "pattern": 1}
+	}
+	if s.Align != "" {
+		style["alignment"] = map[string]string{"horizontal": s.Align, "vertical": "center"}
+	}
+
+	b, _ := json.Marshal(style)
+	return string(b)
+}
+
+// contains tells whether the slice has the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
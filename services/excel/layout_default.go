@@ -0,0 +1,87 @@
+package excel
+
+// defaultLayout is the layout that is used when there is no layout for the request or the account.
+// Custom layouts can be written by copying it; missing parts of them are taken from here.
+const defaultLayout = `
+name: default
+
+brand:
+  name: Carbon
+  color: "#000000"
+
+styles:
+  title: {font: Calibri, size: 12, bold: true, color: "#ffffff", fill: "#000000", align: center}
+  highlight: {bold: true}
+  muted: {color: "#cccccc"}
+
+url:
+  - name: summary
+  - name: all
+    freeze: true
+    filter: true
+    columns:
+      - {field: url, title: URL, width: 40}
+      - {field: status, title: Status, width: 12}
+      - {field: reason, title: Reason, width: 40}
+      - {field: alternative_1, title: Alternative 1, width: 40}
+      - {field: alternative_2, title: Alternative 2, width: 40}
+      - {field: alternative_3, title: Alternative 3, width: 40}
+      - {field: suggested, title: Suggested, width: 40}
+      - {field: input_status, title: Input Status, width: 30, optional: true}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: hits, title: Hits, width: 12, optional: true}
+      - {field: verification, title: Verification, width: 70, optional: true}
+      - {field: provider, title: Provider, width: 15, optional: true}
+      - {field: attempts, title: Attempts, width: 70, optional: true}
+  - name: success
+    columns:
+      - {field: url, title: URL, width: 40}
+      - {field: alternative_1, title: Alternative 1, width: 40}
+      - {field: alternative_2, title: Alternative 2, width: 40}
+      - {field: alternative_3, title: Alternative 3, width: 40}
+      - {field: suggested, title: Suggested, width: 70, style: {fill: "#d5eb81"}}
+      - {field: input_status, title: Input Status, width: 30, optional: true}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: hits, title: Hits, width: 12, optional: true}
+      - {field: verification, title: Verification, width: 70, optional: true}
+      - {field: provider, title: Provider, width: 15, optional: true}
+  - name: fail
+    columns:
+      - {field: url, title: URL, width: 40}
+      - {field: reason, title: Reason, width: 40}
+      - {field: input_status, title: Input Status, width: 30, optional: true}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: hits, title: Hits, width: 12, optional: true}
+      - {field: verification, title: Verification, width: 70, optional: true}
+      - {field: attempts, title: Attempts, width: 70, optional: true}
+  - name: skipped
+    columns:
+      - {field: url, title: URL, width: 40}
+      - {field: reason, title: Reason, width: 40}
+      - {field: input_status, title: Input Status, width: 30, optional: true}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: hits, title: Hits, width: 12, optional: true}
+  - name: warnings
+    columns:
+      - {field: url, title: URL, width: 40}
+      - {field: warning, title: Warning, width: 15}
+      - {field: detail, title: Detail, width: 110}
+
+keyword:
+  - name: summary
+  - name: success
+    columns:
+      - {field: keyword, title: Keyword, width: 40}
+      - {field: position, title: Position}
+      - {field: title, title: Title, width: 40}
+      - {field: url, title: URL, width: 40}
+      - {field: description, title: Description, width: 110}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: provider, title: Provider, width: 15, optional: true}
+  - name: fail
+    columns:
+      - {field: keyword, title: Keyword, width: 40}
+      - {field: reason, title: Reason, width: 40}
+      - {field: duplicates, title: Duplicates, width: 12, optional: true}
+      - {field: attempts, title: Attempts, width: 70, optional: true}
+`
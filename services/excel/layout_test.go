@@ -0,0 +1,112 @@
+package excel
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+func TestConvertURLResultToExcelWithLayout(t *testing.T) {
+	layout, err := ParseLayout([]byte(`{
+		"name": "client-a",
+		"styles": {"title": {"bold": true, "color": "#ffffff", "fill": "#ff6600"}},
+		"url": [
+			{"name": "success", "title": "Redirects", "columns": [
+				{"field": "url", "title": "Old URL", "width": 50},
+				{"field": "suggested", "title": "New URL", "width": 50},
+				{"field": "hits", "title": "Hits", "optional": true}
+			]},
+			{"name": "fail"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Styles.Muted != DefaultLayout().Styles.Muted || len(layout.Keyword) != len(DefaultLayout().Keyword) {
+		t.Fatal("Error: Missing parts must be taken from the default layout.")
+	}
+
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://boratanrikulu.dev/a", "notaavalidurl")
+	urlSet.AddSuccess("https://boratanrikulu.dev/a", []string{"https://boratanrikulu.dev/a-new"})
+
+	f, err := ConvertURLResultToExcelWithLayout(context.Background(), urlSet, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eF, err := excelize.OpenReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheets := eF.GetSheetList()
+	if len(sheets) != 2 || sheets[0] != "Redirects" || sheets[1] != "fail" {
+		t.Fatal("Error: Sheets must be taken from the layout.", sheets)
+	}
+	rows, err := eF.GetRows("Redirects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows[0]) != 2 || rows[0][0] != "Old URL" || rows[1][1] != "https://boratanrikulu.dev/a-new" {
+		t.Fatal("Error: Columns must be taken from the layout.", rows)
+	}
+	rows, err = eF.GetRows("fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0][1] != "Reason" || rows[1][0] != "notaavalidurl" {
+		t.Fatal("Error: Columns must be taken from the default layout.", rows)
+	}
+}
+
+func TestParseLayoutShouldValidate(t *testing.T) {
+	for _, l := range []string{
+		`{"url": [{"name": "unknown"}]}`,
+		`{"url": [{"name": "fail", "columns": [{"field": "suggested"}]}]}`,
+		`{"keyword": [{"name": "success"}, {"name": "fail", "title": "success"}]}`,
+		`{"url": [`,
+		`{"brand": {"color": "orange"}}`,
+		`{"brand": {"logo": "logo.gif"}}`,
+	} {
+		_, err := ParseLayout([]byte(l))
+		if err == nil {
+			t.Fatal("Error: Layout must not be valid.", l)
+		}
+	}
+}
+
+func TestLoadLayout(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "client-a.yaml"), []byte("url:\n  - name: fail\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("LAYOUTS_PATH", dir)
+	defer os.Unsetenv("LAYOUTS_PATH")
+
+	l, err := LoadLayout("client-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "client-a" || len(l.URL) != 1 {
+		t.Fatal("Error: Layout file is not loaded.", l)
+	}
+
+	l, err = LoadLayout("")
+	if err != nil || l.Name != "default" {
+		t.Fatal("Error: Default layout is not loaded.", err)
+	}
+
+	for _, name := range []string{"unknown", "../client-a"} {
+		_, err = LoadLayout(name)
+		if err != ErrLayoutNotFound {
+			t.Fatal("Error: Layout must not be found.", name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package excel
+
+import (
+	"fmt"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+// Report keeps the result as plain tables by using the sheets of the layout.
+// It is used to render the result to other formats, like HTML, PDF and Google Sheets.
+// The summary is always rendered before the tables.
+type Report struct {
+	Type    string // "url" or "keyword".
+	Meta    models.Meta
+	Layout  *Layout
+	Summary *Summary // nil if the layout doesn't have a summary sheet.
+	Tables  []Table
+}
+
+// Table is a sheet of the report, optional columns without data are not listed.
+// Rows are not kept in the table, they are created from the result while they are read by EachRow.
+type Table struct {
+	Title   string
+	Freeze  bool
+	Filter  bool
+	Columns []Column
+	Size    int // count of the rows.
+	rows    records
+}
+
+// Value is a cell of the table.
+type Value struct {
+	Text      string
+	Highlight bool
+	Muted     bool
+}
+
+// URLReport creates a report by using the URLSet and the layout.
+func URLReport(urlSet *models.URLSet, layout *Layout) Report {
+	r := Report{Type: "url", Meta: urlSet.Meta, Layout: layout}
+	withData := optionalFieldsForURLs(urlSet)
+	for _, sheet := range sheetsForURLs(layout, urlSet) {
+		if sheet.Name == "summary" {
+			summary := summaryOfURLs(urlSet)
+			summary.Title = sheet.Title
+			r.Summary = &summary
+			continue
+		}
+		size, rows := recordsForURLs(sheet.Name, urlSet)
+		r.Tables = append(r.Tables, newTable(sheet, withData, size, rows))
+	}
+	return r
+}
+
+// KeywordReport creates a report by using the KeywordSet and the layout.
+func KeywordReport(keywordSet *models.KeywordSet, layout *Layout) Report {
+	r := Report{Type: "keyword", Meta: keywordSet.Meta, Layout: layout}
+	withData := optionalFieldsForKeywords(keywordSet)
+	for _, sheet := range layout.Keyword {
+		if sheet.Name == "summary" {
+			summary := summaryOfKeywords(keywordSet)
+			summary.Title = sheet.Title
+			r.Summary = &summary
+			continue
+		}
+		size, rows := recordsForKeywords(sheet.Name, keywordSet)
+		r.Tables = append(r.Tables, newTable(sheet, withData, size, rows))
+	}
+	return r
+}
+
+// newTable creates the table of the sheet, the rows are read from the result later.
+func newTable(sheet Sheet, withData map[string]bool, size int, rows records) Table {
+	return Table{
+		Title:   sheet.Title,
+		Freeze:  sheet.Freeze,
+		Filter:  sheet.Filter,
+		Columns: visibleColumns(sheet, withData),
+		Size:    size,
+		rows:    rows,
+	}
+}
+
+// EachRow calls f with the values of each row in order, it stops at the first error.
+// The values are not used by the table after f returns, so they can be kept.
+func (t Table) EachRow(f func(values []Value) error) error {
+	if t.rows == nil {
+		return nil
+	}
+	return t.rows(func(r record) error {
+		values := make([]Value, len(t.Columns))
+		for i, c := range t.Columns {
+			v := r[c.Field]
+			if m, ok := v.(mark); ok {
+				values[i].Highlight = m.Style == markHighlight
+				values[i].Muted = m.Style == markMuted
+				v = m.Value
+			}
+			if v != nil {
+				values[i].Text = fmt.Sprint(v)
+			}
+		}
+		return f(values)
+	})
+}
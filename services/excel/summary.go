@@ -0,0 +1,277 @@
+package excel
+
+import (
+	"fmt"
+	neturl "net/url"
+	"sort"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+// percentStyle shows the cell as percentage, like "12.50%".
+var percentStyle = `{"number_format": 10}`
+
+// topDomainsLimit is the count of domains that are listed in the keyword summary.
+const topDomainsLimit = 10
+
+// Summary keeps the request metadata and the counts of the result.
+// It is shown in the summary sheet and the reports.
+type Summary struct {
+	Title  string // title of the summary sheet, it is only set for reports.
+	Meta   models.Meta
+	Tables []SummaryTable
+	Chart  *SummaryChart // nil if there is nothing to show.
+}
+
+// SummaryTable is a table of counts, percentages are calculated by using the total.
+type SummaryTable struct {
+	Title  string
+	Counts []Count
+	Total  int
+}
+
+// SummaryChart tells which table is shown as a chart.
+type SummaryChart struct {
+	Type  string // "pie" or "bar".
+	Table int    // index of the table.
+}
+
+// Count keeps a name with its count to list in the summary tables.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Percentage returns the ratio of the count to the total, like 0.25.
+func (t SummaryTable) Percentage(c Count) float64 {
+	if t.Total == 0 {
+		return 0
+	}
+	return float64(c.Count) / float64(t.Total)
+}
+
+// summaryOfURLs returns counts of statuses and fail reasons, statuses are shown as a chart.
+func summaryOfURLs(urlSet *models.URLSet) Summary {
+	total := len(urlSet.Order)
+
+	reasons := []string{}
+	for _, originalURL := range urlSet.FailOrder() {
+		reasons = append(reasons, urlSet.Fails[originalURL].Reason)
+	}
+
+	return Summary{
+		Meta: urlSet.Meta,
+		Tables: []SummaryTable{
+			{Title: "Status", Total: total, Counts: []Count{
+				{statusSuccess, len(urlSet.Successes)},
+				{statusFail, len(urlSet.Fails)},
+				{statusSkipped, len(urlSet.Skips)},
+			}},
+			{Title: "Reason", Total: total, Counts: countOf(reasons)},
+		},
+		Chart: &SummaryChart{Type: "pie", Table: 0},
+	}
+}
+
+// summaryOfKeywords returns counts of statuses and fail reasons,
+// and the top domains of all results. Top domains are shown as a chart.
+func summaryOfKeywords(keywordSet *models.KeywordSet) Summary {
+	total := len(keywordSet.Order)
+
+	reasons := []string{}
+	for _, keyword := range keywordSet.FailOrder() {
+		reasons = append(reasons, keywordSet.Fails[keyword].Reason)
+	}
+
+	domains := []string{}
+	for _, keyword := range keywordSet.SuccessOrder() {
+		for _, result := range keywordSet.Successes[keyword].Results {
+			if d := domainOf(result.URL); d != "" {
+				domains = append(domains, d)
+			}
+		}
+	}
+	top := countOf(domains)
+	if len(top) > topDomainsLimit {
+		top = top[:topDomainsLimit]
+	}
+
+	summary := Summary{
+		Meta: keywordSet.Meta,
+		Tables: []SummaryTable{
+			{Title: "Status", Total: total, Counts: []Count{
+				{statusSuccess, len(keywordSet.Successes)},
+				{statusFail, len(keywordSet.Fails)},
+			}},
+			{Title: "Reason", Total: total, Counts: countOf(reasons)},
+			{Title: "Domain", Total: len(domains), Counts: top},
+		},
+	}
+	if len(top) != 0 {
+		summary.Chart = &SummaryChart{Type: "bar", Table: 2}
+	}
+	return summary
+}
+
+// createSummarySheet creates summary sheet for the given excel.
+// It shows the request metadata, the tables and the chart.
+func createSummarySheet(f *excelize.File, s *styles, sheet string, summary Summary) error {
+	row, err := setSummaryMeta(f, s, sheet, summary.Meta)
+	if err != nil {
+		return err
+	}
+
+	starts := []int{}
+	for _, table := range summary.Tables {
+		starts = append(starts, row+1)
+		row, err = setSummaryTable(f, s, sheet, row, table)
+		if err != nil {
+			return err
+		}
+		row++
+	}
+
+	if summary.Chart == nil {
+		return nil
+	}
+	table := summary.Tables[summary.Chart.Table]
+	return addSummaryChart(f, sheet, summary.Chart.Type, table.Title, starts[summary.Chart.Table], len(table.Counts))
+}
+
+// setSummaryMeta sets the request metadata to the top of the summary sheet.
+// It returns the next empty row.
+func setSummaryMeta(f *excelize.File, s *styles, sheet string, meta models.Meta) (int, error) {
+	rows := [][]interface{}{}
+	for _, field := range MetaFields(meta) {
+		rows = append(rows, []interface{}{field[0], field[1]})
+	}
+
+	for i, values := range rows {
+		err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", i+1), &values)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Set styles
+	err := f.SetColWidth(sheet, "A", "A", 40)
+	if err != nil {
+		return 0, err
+	}
+	err = f.SetColWidth(sheet, "B", "C", 15)
+	if err != nil {
+		return 0, err
+	}
+	err = f.SetCellStyle(sheet, "A1", fmt.Sprintf("A%d", len(rows)), s.Highlight)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rows) + 2, nil
+}
+
+// MetaFields returns the request metadata as label and value pairs.
+func MetaFields(meta models.Meta) [][2]string {
+	providers := strings.Join(meta.Providers, ", ")
+	if providers == "" {
+		providers = "-"
+	}
+	fields := [][2]string{
+		{"Type", meta.Type},
+		{"Country", meta.Country},
+		{"Language", meta.Language},
+		{"Date", meta.Date.UTC().Format("2006-01-02 15:04:05 MST")},
+		{"Providers", providers},
+	}
+	if meta.TimedOut {
+		fields = append(fields, [2]string{"Result", "Partial, the deadline is reached"})
+	}
+	return fields
+}
+
+// setSummaryTable sets a table with "Count" and "Percentage" columns, starting at the given row.
+// It returns the next empty row.
+func setSummaryTable(f *excelize.File, s *styles, sheet string, row int, table SummaryTable) (int, error) {
+	letters := []string{"A", "B", "C"}
+	titles := []string{table.Title, "Count", "Percentage"}
+	// NOTE: letters and titles sizes must be same!
+
+	// Set titles.
+	for i, letter := range letters {
+		err := f.SetCellValue(sheet, fmt.Sprintf("%s%d", letter, row), titles[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+	err := f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("C%d", row), s.Title)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range table.Counts {
+		row++
+		values := []interface{}{c.Name, c.Count, table.Percentage(c)}
+		err := f.SetSheetRow(sheet, fmt.Sprintf("A%d", row), &values)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(table.Counts) != 0 {
+		err := f.SetCellStyle(sheet, fmt.Sprintf("C%d", row-len(table.Counts)+1), fmt.Sprintf("C%d", row), s.Percent)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return row + 1, nil
+}
+
+// addSummaryChart adds a chart next to the tables by using the rows starting at the given row.
+func addSummaryChart(f *excelize.File, sheet, chartType, title string, row, size int) error {
+	ref := "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+	return f.AddChart(sheet, "E2", fmt.Sprintf(`{
+		"type": "%s",
+		"series": [{
+			"name": "%s",
+			"categories": "%s!$A$%d:$A$%d",
+			"values": "%s!$B$%d:$B$%d"
+		}],
+		"title": {"name": "%s"},
+		"legend": {"position": "right"},
+		"plotarea": {"show_val": true}
+	}`, chartType, title, ref, row, row+size-1, ref, row, row+size-1, title))
+}
+
+// countOf counts the values, the result is sorted by the count and then by the name.
+func countOf(values []string) []Count {
+	counts := make(map[string]int)
+	for _, v := range values {
+		counts[v]++
+	}
+
+	r := []Count{}
+	for name, c := range counts {
+		r = append(r, Count{Name: name, Count: c})
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Count != r[j].Count {
+			return r[i].Count > r[j].Count
+		}
+		return r[i].Name < r[j].Name
+	})
+
+	return r
+}
+
+// domainOf returns the host of the URL without "www.".
+func domainOf(u string) string {
+	parsed, err := neturl.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
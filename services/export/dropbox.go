@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/oauth2"
+)
+
+// DropboxExporter uploads files to a Dropbox folder, and returns a shared link of them.
+type DropboxExporter struct {
+	ContentURL string       // "https://content.dropboxapi.com/2" by default.
+	APIURL     string       // "https://api.dropboxapi.com/2" by default.
+	Folder     string       // like "/Carbon".
+	Client     *http.Client // must add the token.
+}
+
+// NewDropboxExporterFromEnv creates a DropboxExporter by using the env file.
+// A refresh token with the app key and secret is used if it is set, otherwise the access token is used.
+func NewDropboxExporterFromEnv() (*DropboxExporter, error) {
+	var src oauth2.TokenSource
+	if os.Getenv("DROPBOX_REFRESH_TOKEN") != "" {
+		values, err := requireEnv("DROPBOX_APP_KEY", "DROPBOX_APP_SECRET")
+		if err != nil {
+			return nil, err
+		}
+		config := &oauth2.Config{
+			ClientID:     values[0],
+			ClientSecret: values[1],
+			Endpoint:     oauth2.Endpoint{TokenURL: "https://api.dropboxapi.com/oauth2/token"},
+		}
+		src = config.TokenSource(context.Background(), &oauth2.Token{RefreshToken: os.Getenv("DROPBOX_REFRESH_TOKEN")})
+	} else {
+		values, err := requireEnv("DROPBOX_ACCESS_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		src = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: values[0]})
+	}
+
+	return &DropboxExporter{
+		ContentURL: "https://content.dropboxapi.com/2",
+		APIURL:     "https://api.dropboxapi.com/2",
+		Folder:     envOr("DROPBOX_FOLDER", "/Carbon"),
+		Client:     oauth2.NewClient(context.Background(), src),
+	}, nil
+}
+
+// Export uploads the file, names are changed by Dropbox if they are used.
+// The link downloads the file directly instead of showing the preview.
+func (e *DropboxExporter) Export(ctx context.Context, f File) (string, error) {
+	arg, err := apiArg(map[string]interface{}{
+		"path":       "/" + strings.Trim(e.Folder, "/") + "/" + f.Name,
+		"mode":       "add",
+		"autorename": true,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.ContentURL, "/")+"/files/upload", bytes.NewReader(f.Body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Dropbox-API-Arg", arg)
+
+	var file struct {
+		PathLower string `json:"path_lower"`
+	}
+	err = e.do(req, &file, "Error occur while uploading the file to Dropbox:")
+	if err != nil {
+		return "", err
+	}
+
+	var link struct {
+		URL string `json:"url"`
+	}
+	err = e.call(ctx, "/sharing/create_shared_link_with_settings", map[string]string{"path": file.PathLower}, &link)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(link.URL, "dl=0", "dl=1", 1), nil
+}
+
+// call sends the request to the API, and decodes the response to out.
+func (e *DropboxExporter) call(ctx context.Context, path string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.APIURL, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return e.do(req, out, "Error occur while calling Dropbox:")
+}
+
+// do sends the request, and decodes the response to out.
+func (e *DropboxExporter) do(req *http.Request, out interface{}, message string) error {
+	client := e.Client
+	if client == nil {
+		client = httpClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %w", message, err)
+	}
+	defer res.Body.Close()
+	err = checkResponse(res, message)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// apiArg encodes the argument for the Dropbox-API-Arg header, non-ASCII characters must be escaped in headers.
+func apiArg(arg interface{}) (string, error) {
+	b, err := json.Marshal(arg)
+	if err != nil {
+		return "", err
+	}
+	var s strings.Builder
+	for _, r := range string(b) {
+		if r < 0x80 {
+			s.WriteRune(r)
+			continue
+		}
+		for _, c := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&s, "\\u%04x", c)
+		}
+	}
+	return s.String(), nil
+}
@@ -0,0 +1,39 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDropboxExporter(t *testing.T) {
+	var arg string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/content/files/upload":
+			arg = r.Header.Get("Dropbox-API-Arg")
+			json.NewEncoder(w).Encode(map[string]string{"path_lower": "/carbon/sonuç (1).xlsx"})
+		case "/api/sharing/create_shared_link_with_settings":
+			body := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(map[string]string{"url": "https://www.dropbox.com/s/x" + body["path"] + "?dl=0"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	e := &DropboxExporter{ContentURL: server.URL + "/content", APIURL: server.URL + "/api", Folder: "/Carbon", Client: server.Client()}
+	link, err := e.Export(context.Background(), File{Name: "sonuç.xlsx", Body: []byte("file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg != `{"autorename":true,"mode":"add","path":"/Carbon/sonu\u00e7.xlsx"}` {
+		t.Fatal("Error: API arg must be escaped.", arg)
+	}
+	if link != "https://www.dropbox.com/s/x/carbon/sonuç (1).xlsx?dl=1" {
+		t.Fatal("Error: Link must download the file.", link)
+	}
+}
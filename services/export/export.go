@@ -0,0 +1,103 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Destinations of the exports.
+const (
+	DestinationS3       = "s3"
+	DestinationOneDrive = "onedrive"
+	DestinationDropbox  = "dropbox"
+	DestinationWebhook  = "webhook"
+)
+
+// Destinations are the supported destinations, in the order of the docs.
+var Destinations = []string{DestinationS3, DestinationOneDrive, DestinationDropbox, DestinationWebhook}
+
+// File is the result that is exported.
+type File struct {
+	Name        string // like "result.xlsx".
+	ContentType string
+	Body        []byte
+}
+
+// Exporter uploads the file to a destination, and returns the link to download it.
+// The link can be empty if the destination doesn't give one, like webhooks.
+type Exporter interface {
+	Export(ctx context.Context, f File) (string, error)
+}
+
+// NewExporterFromEnv creates the exporter of the destination by using the env file.
+func NewExporterFromEnv(destination string) (Exporter, error) {
+	switch destination {
+	case DestinationS3:
+		return NewS3ExporterFromEnv()
+	case DestinationOneDrive:
+		return NewOneDriveExporterFromEnv()
+	case DestinationDropbox:
+		return NewDropboxExporterFromEnv()
+	case DestinationWebhook:
+		return NewWebhookExporterFromEnv()
+	default:
+		return nil, fmt.Errorf("Destination \"%s\" is not supported.", destination)
+	}
+}
+
+// IsDestination tells whether the destination is supported.
+func IsDestination(destination string) bool {
+	for _, d := range Destinations {
+		if d == destination {
+			return true
+		}
+	}
+	return false
+}
+
+// httpClient is used by the exporters if they don't have a client.
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// requireEnv returns the values of the env variables, it fails if one of them is not set.
+func requireEnv(names ...string) ([]string, error) {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = os.Getenv(name)
+		if values[i] == "" {
+			return nil, fmt.Errorf("%s is not set.", name)
+		}
+	}
+	return values, nil
+}
+
+// envOr returns the value of the env variable, or the default one if it is not set.
+func envOr(name, defaultValue string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// objectName returns a unique name for the file, like "2020-12-01/5f1c2a7e9b3d4c10/result.xlsx".
+// Names can't be guessed, so links of the files are not found by others.
+func objectName(name string, now time.Time) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return now.UTC().Format("2006-01-02") + "/" + hex.EncodeToString(b) + "/" + name
+}
+
+// checkResponse returns an error with the status and the body if the response is not successful.
+func checkResponse(res *http.Response, message string) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("%s %s: %s", message, res.Status, body)
+}
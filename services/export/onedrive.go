@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// uploadChunkSize is the size of the chunks in upload sessions, it must be a multiple of 320 KiB.
+const uploadChunkSize = 10 * 320 * 1024
+
+// OneDriveExporter uploads files to a OneDrive or SharePoint document library by using Microsoft Graph,
+// and returns a sharing link of them.
+type OneDriveExporter struct {
+	BaseURL   string       // "https://graph.microsoft.com/v1.0" by default.
+	DriveID   string       // the drive of the user or the document library of the SharePoint site.
+	Folder    string       // like "Carbon", it is created if it doesn't exist.
+	LinkScope string       // "anonymous" or "organization".
+	Client    *http.Client // must add the token, upload URLs are called without it.
+}
+
+// NewOneDriveExporterFromEnv creates a OneDriveExporter with an app-only token by using the env file.
+func NewOneDriveExporterFromEnv() (*OneDriveExporter, error) {
+	values, err := requireEnv("ONEDRIVE_TENANT_ID", "ONEDRIVE_CLIENT_ID", "ONEDRIVE_CLIENT_SECRET", "ONEDRIVE_DRIVE_ID")
+	if err != nil {
+		return nil, err
+	}
+	scope := envOr("ONEDRIVE_LINK_SCOPE", "anonymous")
+	if scope != "anonymous" && scope != "organization" {
+		return nil, fmt.Errorf("ONEDRIVE_LINK_SCOPE must be \"anonymous\" or \"organization\".")
+	}
+
+	config := &clientcredentials.Config{
+		ClientID:     values[1],
+		ClientSecret: values[2],
+		TokenURL:     "https://login.microsoftonline.com/" + url.PathEscape(values[0]) + "/oauth2/v2.0/token",
+		Scopes:       []string{"https://graph.microsoft.com/.default"},
+	}
+	return &OneDriveExporter{
+		BaseURL:   "https://graph.microsoft.com/v1.0",
+		DriveID:   values[3],
+		Folder:    envOr("ONEDRIVE_FOLDER", "Carbon"),
+		LinkScope: scope,
+		Client:    config.Client(context.Background()),
+	}, nil
+}
+
+// Export uploads the file with an upload session, so big files are sent as chunks.
+func (e *OneDriveExporter) Export(ctx context.Context, f File) (string, error) {
+	path := strings.Trim(e.Folder, "/") + "/" + f.Name
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	var session struct {
+		UploadURL string `json:"uploadUrl"`
+	}
+	err := e.call(ctx, http.MethodPost, "/drives/"+url.PathEscape(e.DriveID)+"/root:/"+strings.Join(segments, "/")+":/createUploadSession",
+		map[string]interface{}{"item": map[string]string{"@microsoft.graph.conflictBehavior": "rename"}}, &session)
+	if err != nil {
+		return "", err
+	}
+
+	itemID, err := e.upload(ctx, session.UploadURL, f.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var link struct {
+		Link struct {
+			WebURL string `json:"webUrl"`
+		} `json:"link"`
+	}
+	err = e.call(ctx, http.MethodPost, "/drives/"+url.PathEscape(e.DriveID)+"/items/"+url.PathEscape(itemID)+"/createLink",
+		map[string]string{"type": "view", "scope": e.LinkScope}, &link)
+	if err != nil {
+		return "", err
+	}
+	return link.Link.WebURL, nil
+}
+
+// upload sends the body to the upload URL as chunks, and returns the ID of the item.
+// Upload URLs are authenticated by themselves, the token must not be sent.
+func (e *OneDriveExporter) upload(ctx context.Context, uploadURL string, body []byte) (string, error) {
+	for start := 0; start < len(body); start += uploadChunkSize {
+		end := start + uploadChunkSize
+		if end > len(body) {
+			end = len(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(body[start:end]))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(body)))
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("Error occur while uploading the file to OneDrive: %w", err)
+		}
+		err = checkResponse(res, "Error occur while uploading the file to OneDrive:")
+		if err != nil {
+			res.Body.Close()
+			return "", err
+		}
+
+		// The last response is the uploaded item.
+		if end == len(body) {
+			var item struct {
+				ID string `json:"id"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&item)
+			res.Body.Close()
+			if err != nil || item.ID == "" {
+				return "", fmt.Errorf("Error occur while uploading the file to OneDrive: item is not returned.")
+			}
+			return item.ID, nil
+		}
+		res.Body.Close()
+	}
+	return "", fmt.Errorf("Error occur while uploading the file to OneDrive: item is not returned.")
+}
+
+// call sends the request to Graph API, and decodes the response to out.
+func (e *OneDriveExporter) call(ctx context.Context, method, path string, in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.BaseURL, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = httpClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error occur while calling OneDrive: %w", err)
+	}
+	defer res.Body.Close()
+	err = checkResponse(res, "Error occur while calling OneDrive:")
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
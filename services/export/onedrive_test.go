@@ -0,0 +1,52 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOneDriveExporter(t *testing.T) {
+	var server *httptest.Server
+	var uploaded []byte
+	var scope string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drives/drive-id/root:/Carbon/result.xlsx:/createUploadSession":
+			json.NewEncoder(w).Encode(map[string]string{"uploadUrl": server.URL + "/upload"})
+		case "/upload":
+			b, _ := ioutil.ReadAll(r.Body)
+			uploaded = append(uploaded, b...)
+			if len(uploaded) < 2*uploadChunkSize+1 {
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte(`{}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"id": "item-id"})
+		case "/drives/drive-id/items/item-id/createLink":
+			body := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&body)
+			scope = body["scope"]
+			json.NewEncoder(w).Encode(map[string]interface{}{"link": map[string]string{"webUrl": "https://1drv.ms/x/item-id"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	e := &OneDriveExporter{BaseURL: server.URL, DriveID: "drive-id", Folder: "/Carbon/", LinkScope: "organization", Client: server.Client()}
+	link, err := e.Export(context.Background(), File{Name: "result.xlsx", Body: make([]byte, 2*uploadChunkSize+1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://1drv.ms/x/item-id" || scope != "organization" {
+		t.Fatal("Error: Link is not created.", link, scope)
+	}
+	if len(uploaded) != 2*uploadChunkSize+1 {
+		t.Fatal("Error: File is not uploaded as chunks.", len(uploaded))
+	}
+}
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Exporter uploads files to S3 or a compatible storage like MinIO,
+// and returns presigned links, so the bucket can be private.
+type S3Exporter struct {
+	Endpoint  string // like "https://minio.example.com", AWS is used if it is not set.
+	Region    string
+	Bucket    string
+	Prefix    string // added to object names, like "carbon/".
+	AccessKey string
+	SecretKey string
+	Expires   time.Duration // lifetime of the links, 7 days at most.
+	Client    *http.Client
+}
+
+// NewS3ExporterFromEnv creates an S3Exporter by using the env file.
+func NewS3ExporterFromEnv() (*S3Exporter, error) {
+	values, err := requireEnv("S3_BUCKET", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY")
+	if err != nil {
+		return nil, err
+	}
+	hours, err := strconv.Atoi(envOr("S3_LINK_EXPIRES_HOURS", "24"))
+	if err != nil || hours <= 0 || hours > 168 {
+		return nil, fmt.Errorf("S3_LINK_EXPIRES_HOURS must be between 1 and 168.")
+	}
+	return &S3Exporter{
+		Endpoint:  strings.TrimSuffix(envOr("S3_ENDPOINT", ""), "/"),
+		Region:    envOr("S3_REGION", "us-east-1"),
+		Bucket:    values[0],
+		Prefix:    envOr("S3_PREFIX", "carbon/"),
+		AccessKey: values[1],
+		SecretKey: values[2],
+		Expires:   time.Duration(hours) * time.Hour,
+	}, nil
+}
+
+// Export uploads the file with a presigned PUT, and returns a presigned GET for it.
+func (e *S3Exporter) Export(ctx context.Context, f File) (string, error) {
+	now := time.Now()
+	key := e.Prefix + objectName(f.Name, now)
+
+	uploadURL := e.presign(http.MethodPut, key, nil, 15*time.Minute, now)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(f.Body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", f.ContentType)
+	res, err := e.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error occur while uploading the file to S3: %w", err)
+	}
+	defer res.Body.Close()
+	err = checkResponse(res, "Error occur while uploading the file to S3:")
+	if err != nil {
+		return "", err
+	}
+
+	// The file is downloaded with its name, not with the unique key.
+	query := url.Values{"response-content-disposition": {`attachment; filename="` + f.Name + `"`}}
+	return e.presign(http.MethodGet, key, query, e.Expires, now), nil
+}
+
+// presign returns the URL that is signed by using AWS Signature Version 4 with the query.
+// The payload is not signed, so the same URL is used for any body.
+func (e *S3Exporter) presign(method, key string, query url.Values, expires time.Duration, now time.Time) string {
+	host, path := e.location(key)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + e.Region + "/s3/aws4_request"
+
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", e.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery(q),
+		"host:" + hostOf(host) + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key4 := hmacSHA256([]byte("AWS4"+e.SecretKey), amzDate[:8])
+	key4 = hmacSHA256(key4, e.Region)
+	key4 = hmacSHA256(key4, "s3")
+	key4 = hmacSHA256(key4, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key4, stringToSign))
+
+	return host + path + "?" + canonicalQuery(q) + "&X-Amz-Signature=" + signature
+}
+
+// location returns the host and the path of the object.
+// AWS uses virtual-hosted style, custom endpoints like MinIO use path style.
+func (e *S3Exporter) location(key string) (string, string) {
+	if e.Endpoint == "" {
+		host := "https://" + e.Bucket + ".s3.amazonaws.com"
+		if e.Region != "us-east-1" {
+			host = "https://" + e.Bucket + ".s3." + e.Region + ".amazonaws.com"
+		}
+		return host, "/" + uriEncode(key, false)
+	}
+	return e.Endpoint, "/" + uriEncode(e.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+// client returns the client of the exporter, or the default one.
+func (e *S3Exporter) client() *http.Client {
+	if e.Client != nil {
+		return e.Client
+	}
+	return httpClient
+}
+
+// hostOf returns the host of the URL with the port, like "minio:9000".
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Host
+}
+
+// canonicalQuery encodes the query as AWS wants; sorted by keys, spaces as "%20".
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes all characters except the unreserved ones, slashes are kept if encodeSlash is false.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
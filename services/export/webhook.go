@@ -0,0 +1,53 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WebhookExporter posts files to an URL, like an internal service that keeps the results.
+type WebhookExporter struct {
+	URL    string
+	Token  string // sent as a bearer token, if it is set.
+	Client *http.Client
+}
+
+// NewWebhookExporterFromEnv creates a WebhookExporter by using the env file.
+func NewWebhookExporterFromEnv() (*WebhookExporter, error) {
+	values, err := requireEnv("EXPORT_WEBHOOK_URL")
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookExporter{URL: values[0], Token: envOr("EXPORT_WEBHOOK_TOKEN", "")}, nil
+}
+
+// Export posts the file as the body, the name is sent in Content-Disposition header.
+// The Location header of the response is returned as the link, if it is set.
+func (e *WebhookExporter) Export(ctx context.Context, f File) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(f.Body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", f.ContentType)
+	req.Header.Set("Content-Disposition", `attachment; filename="`+f.Name+`"`)
+	if e.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.Token)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = httpClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error occur while posting the file to the webhook: %w", err)
+	}
+	defer res.Body.Close()
+	err = checkResponse(res, "Error occur while posting the file to the webhook:")
+	if err != nil {
+		return "", err
+	}
+	return res.Header.Get("Location"), nil
+}
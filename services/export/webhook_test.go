@@ -0,0 +1,33 @@
+package export
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookExporter(t *testing.T) {
+	var auth, disposition string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, disposition = r.Header.Get("Authorization"), r.Header.Get("Content-Disposition")
+		w.Header().Set("Location", "https://files.example.com/result.pdf")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	e := &WebhookExporter{URL: server.URL, Token: "secret", Client: server.Client()}
+	link, err := e.Export(context.Background(), File{Name: "result.pdf", ContentType: "application/pdf", Body: []byte("file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://files.example.com/result.pdf" || auth != "Bearer secret" || disposition != `attachment; filename="result.pdf"` {
+		t.Fatal("Error: File is not posted.", link, auth, disposition)
+	}
+
+	e.URL = server.URL + "/fail"
+	server.Config.Handler = http.NotFoundHandler()
+	if _, err := e.Export(context.Background(), File{Name: "result.pdf"}); err == nil {
+		t.Fatal("Error: Failed responses must return an error.")
+	}
+}
@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// States of the breaker.
+const (
+	closed = iota
+	open
+	halfOpen
+)
+
+// breaker stops sending requests to an endpoint after consecutive failures.
+// After the cooldown, a trial request is sent; the circuit is closed if it succeeds, or opened again.
+type breaker struct {
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	trial    bool // a trial request is in flight.
+}
+
+// stateName returns the state as "closed", "open" or "half-open".
+func (b *breaker) stateName() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// allow tells whether a request can be sent.
+func (b *breaker) allow(now time.Time, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if now.Sub(b.openedAt) < cooldown {
+			return false
+		}
+		b.state, b.trial = halfOpen, true
+		return true
+	case halfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// record keeps the result of the request, it returns true if the circuit is opened by this failure.
+func (b *breaker) record(failed bool, now time.Time, threshold int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state, b.failures, b.trial = closed, 0, false
+		return false
+	}
+
+	b.failures++
+	if b.state == halfOpen || (b.state == closed && b.failures >= threshold) {
+		b.state, b.openedAt, b.trial = open, now, false
+		return true
+	}
+	return false
+}
+
+// release ends the trial request without a result, so another trial can be sent.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == halfOpen {
+		b.trial = false
+	}
+}
@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request when the endpoint keeps failing.
+var ErrCircuitOpen = errors.New("Circuit is open for the endpoint.")
+
+// ErrInvalidURL is returned for requests without a scheme or a host, like the ones to an address that is not set.
+var ErrInvalidURL = errors.New("URL must have a scheme and a host.")
+
+// Client sends requests with timeouts, retries and circuit breakers.
+// It is shared by the providers, so breakers and metrics are kept per endpoint (scheme, host and path).
+type Client struct {
+	HTTP       *http.Client
+	Timeout    time.Duration // timeout of an attempt, including reading the body.
+	Retries    int           // retries after the first attempt.
+	Backoff    time.Duration // base of the exponential backoff.
+	MaxBackoff time.Duration
+	Threshold  int           // consecutive failures to open the circuit.
+	Cooldown   time.Duration // the circuit is half-opened after the cooldown, and a trial request is sent.
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	metrics  map[string]*Metrics
+}
+
+// Metrics are the counts of an endpoint.
+type Metrics struct {
+	Circuit   string        `json:"circuit"`   // state of the breaker, it is set by Client.Metrics.
+	Requests  int64         `json:"requests"`  // attempts that are sent.
+	Retries   int64         `json:"retries"`   // attempts that are sent again.
+	Failures  int64         `json:"failures"`  // requests that are failed after all attempts.
+	Rejected  int64         `json:"rejected"`  // requests that are not sent because the circuit is open.
+	Latency   time.Duration `json:"-"`         // total latency of the attempts.
+	LatencyMs int64         `json:"latencyMs"` // average latency of the attempts, it is set by Client.Metrics.
+}
+
+// New creates a client with the defaults.
+func New() *Client {
+	return &Client{
+		HTTP:       &http.Client{},
+		Timeout:    30 * time.Second,
+		Retries:    3,
+		Backoff:    200 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+		Threshold:  5,
+		Cooldown:   30 * time.Second,
+	}
+}
+
+// NewFromEnv creates a client, the defaults are overridden by HTTP_TIMEOUT_SECONDS, HTTP_RETRIES,
+// HTTP_BACKOFF_MS, BREAKER_THRESHOLD and BREAKER_COOLDOWN_SECONDS.
+func NewFromEnv() *Client {
+	c := New()
+	if v, err := strconv.Atoi(os.Getenv("HTTP_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		c.Timeout = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("HTTP_RETRIES")); err == nil && v >= 0 {
+		c.Retries = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HTTP_BACKOFF_MS")); err == nil && v > 0 {
+		c.Backoff = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("BREAKER_THRESHOLD")); err == nil && v > 0 {
+		c.Threshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("BREAKER_COOLDOWN_SECONDS")); err == nil && v > 0 {
+		c.Cooldown = time.Duration(v) * time.Second
+	}
+	return c
+}
+
+var (
+	defaultClient *Client
+	defaultOnce   sync.Once
+)
+
+// Default returns the client that is defined at the env.
+// The client is created only once for the lambda instance, so breakers are kept between requests.
+func Default() *Client {
+	defaultOnce.Do(func() {
+		defaultClient = NewFromEnv()
+	})
+	return defaultClient
+}
+
+// noRetryKey is the context key of the statuses that are not retried, see WithoutRetries.
+type noRetryKey struct{}
+
+// WithoutRetries returns a context that makes Do return the responses of the statuses without retrying them.
+// It is used for the requests whose caller handles the statuses, like trying the next API key for 429.
+func WithoutRetries(ctx context.Context, statuses ...int) context.Context {
+	noRetry := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		noRetry[status] = true
+	}
+	return context.WithValue(ctx, noRetryKey{}, noRetry)
+}
+
+// Do sends the request, network errors, 429 and 5xx responses are retried with jittered backoff.
+// The last response is returned if all attempts fail with a status, the caller must check it.
+// Requests that have bodies must be created by http.NewRequest, so the body can be sent again.
+// Statuses of WithoutRetries in the context of the request are returned at once.
+// Requests without a scheme or a host are rejected before their breaker, so they don't share one.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.URL == nil || req.URL.Scheme == "" || req.URL.Host == "" {
+		return nil, fmt.Errorf("%w \"%s\"", ErrInvalidURL, req.URL)
+	}
+	endpoint := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	b, m := c.of(endpoint)
+
+	for attempt := 0; ; attempt++ {
+		if !b.allow(time.Now(), c.Cooldown) {
+			c.count(func() { m.Rejected++ })
+			return nil, fmt.Errorf("%w %s", ErrCircuitOpen, endpoint)
+		}
+
+		start := time.Now()
+		res, err := c.send(req)
+		c.count(func() { m.Requests++; m.Latency += time.Since(start) })
+
+		// Cancelled requests don't tell the health of the endpoint, so they are not recorded.
+		if req.Context().Err() != nil {
+			b.release()
+			if err == nil {
+				return res, nil
+			}
+			return nil, req.Context().Err()
+		}
+
+		failed := err != nil || res.StatusCode >= 500
+		if b.record(failed, time.Now(), c.Threshold) {
+			log.Printf("Error: Circuit is opened for %s.\n", endpoint)
+		}
+
+		retry := failed || res.StatusCode == http.StatusTooManyRequests
+		if noRetry, _ := req.Context().Value(noRetryKey{}).(map[int]bool); res != nil && noRetry[res.StatusCode] {
+			retry = false
+		}
+		canRetry := attempt < c.Retries && (req.Body == nil || req.GetBody != nil) && req.Context().Err() == nil
+		if !retry || !canRetry {
+			if retry {
+				c.count(func() { m.Failures++ })
+			}
+			return res, err
+		}
+
+		wait := c.backoff(attempt)
+		if res != nil {
+			// Retry-After of the provider is used, it is limited by the timeout.
+			if after, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && after > 0 {
+				wait = time.Duration(after) * time.Second
+				if wait > c.Timeout {
+					wait = c.Timeout
+				}
+			}
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64*1024))
+			res.Body.Close()
+		}
+
+		c.count(func() { m.Retries++ })
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			c.count(func() { m.Failures++ })
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// Metrics returns a copy of the metrics of the endpoints with the states of their circuits.
+func (c *Client) Metrics() map[string]Metrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := make(map[string]Metrics, len(c.metrics))
+	for endpoint, m := range c.metrics {
+		copied := *m
+		copied.Circuit = c.breakers[endpoint].stateName()
+		if m.Requests > 0 {
+			copied.LatencyMs = (m.Latency / time.Duration(m.Requests)).Milliseconds()
+		}
+		r[endpoint] = copied
+	}
+	return r
+}
+
+// send sends an attempt with its own timeout, the timeout is cancelled when the body is closed.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.Timeout)
+	r := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	res, err := c.HTTP.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// backoff returns the waiting time before the retry, it is between the half and the whole of the exponential backoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.Backoff << uint(attempt)
+	if d > c.MaxBackoff || d <= 0 {
+		d = c.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// of returns the breaker and the metrics of the endpoint.
+func (c *Client) of(endpoint string) (*breaker, *Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.breakers == nil {
+		c.breakers = make(map[string]*breaker)
+		c.metrics = make(map[string]*Metrics)
+	}
+	if _, ok := c.breakers[endpoint]; !ok {
+		c.breakers[endpoint] = &breaker{}
+		c.metrics[endpoint] = &Metrics{}
+	}
+	return c.breakers[endpoint], c.metrics[endpoint]
+}
+
+// count updates the metrics.
+func (c *Client) count(update func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update()
+}
+
+// cancelBody cancels the context of the attempt when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client with short waits.
+func newTestClient(server *httptest.Server) *Client {
+	c := New()
+	c.HTTP = server.Client()
+	c.Backoff, c.MaxBackoff = time.Millisecond, 5*time.Millisecond
+	c.Threshold, c.Cooldown = 3, 50*time.Millisecond
+	return c
+}
+
+func TestClientShouldRetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/serp", strings.NewReader("payload"))
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if attempts != 3 || string(body) != "payload" {
+		t.Fatal("Error: Request is not retried with its body.", attempts, string(body))
+	}
+
+	m := c.Metrics()[server.URL+"/serp"]
+	if m.Requests != 3 || m.Retries != 2 || m.Failures != 0 {
+		t.Fatal("Error: Metrics are not valid.", m)
+	}
+}
+
+func TestClientShouldNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err := newTestClient(server).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if attempts != 1 || res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("Error: Client errors must not be retried.", attempts)
+	}
+}
+
+func TestClientShouldNotRetryStatusesOfTheContext(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	req, _ := http.NewRequestWithContext(WithoutRetries(context.Background(), http.StatusTooManyRequests), http.MethodGet, server.URL, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusTooManyRequests || attempts != 1 {
+		t.Fatal("Error: Statuses of the context must not be retried.", res.StatusCode, attempts)
+	}
+	if m := c.Metrics()[server.URL]; m.Failures != 0 || m.Retries != 0 {
+		t.Fatal("Error: Metrics are not valid.", m)
+	}
+}
+
+func TestClientShouldOpenCircuit(t *testing.T) {
+	attempts := 0
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	c.Retries = 0
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, ErrCircuitOpen) || attempts != 3 {
+		t.Fatal("Error: Circuit must be opened after the failures.", attempts, err)
+	}
+	if m := c.Metrics()[server.URL]; m.Circuit != "open" {
+		t.Fatal("Error: Circuit state is not in the metrics.", m)
+	}
+
+	// The trial request closes the circuit after the cooldown.
+	time.Sleep(60 * time.Millisecond)
+	healthy = true
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal("Error: Circuit must be closed after the cooldown.", err)
+		}
+		res.Body.Close()
+	}
+	if m := c.Metrics()[server.URL]; m.Rejected != 1 || m.Failures != 3 || m.Circuit != "closed" {
+		t.Fatal("Error: Metrics are not valid.", m)
+	}
+}
+
+func TestClientShouldNotOpenCircuitForCancelledRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if _, err := c.Do(req); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("Error: Request must fail with its context.", err)
+		}
+		cancel()
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal("Error: Cancelled requests must not open the circuit.", err)
+	}
+	res.Body.Close()
+	if m := c.Metrics()[server.URL]; m.Failures != 0 || m.Retries != 0 {
+		t.Fatal("Error: Cancelled requests must not be failures.", m)
+	}
+}
+
+func TestClientShouldStopWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	c.Backoff, c.MaxBackoff = time.Second, time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := c.Do(req); err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Fatal("Error: Retries must stop when the context is done.", err)
+	}
+}
+
+func TestClientShouldRejectURLsWithoutHost(t *testing.T) {
+	c := New()
+	c.Threshold = 1
+	for _, u := range []string{"", "/v3/serp", "https://"} {
+		req, _ := http.NewRequest(http.MethodPost, u, nil)
+		if _, err := c.Do(req); !errors.Is(err, ErrInvalidURL) {
+			t.Fatal("Error: URLs without a host must be rejected.", u, err)
+		}
+	}
+	if len(c.Metrics()) != 0 {
+		t.Fatal("Error: Invalid URLs must not have a breaker.", c.Metrics())
+	}
+}
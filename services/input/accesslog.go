@@ -0,0 +1,173 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hit keeps a 404 URL with the count of requests in the access log.
+type Hit struct {
+	URL   string
+	Count int
+}
+
+// combinedLog matches nginx/Apache combined (and common) log lines.
+// The virtual host prefix ("%v:%p") of Apache's vhost_combined format is optional.
+//
+// Example:
+// 127.0.0.1 - - [10/Oct/2020:13:55:36 +0300] "GET /blog/old-post HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+var combinedLog = regexp.MustCompile(`^(?:(\S+?)(?::\d+)? )?\S+ \S+ \S+ \[[^\]]+\] "\S+ (\S+)[^"]*" (\d{3}) `)
+
+// ParseAccessLog returns unique 404 URLs of the host from the access log, ranked by hit count.
+//
+// The log can be in combined format or JSON lines, and it can be gzipped.
+// If the body is a form, the first file in the form is used.
+// Lines that include another host are skipped.
+// The scheme is "http" or "https" (default), it is used if the line doesn't tell its scheme.
+func ParseAccessLog(contentType string, body []byte, host, scheme string) ([]Hit, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return nil, errors.New("host is not set.")
+	}
+
+	if mediaType, params, err := mime.ParseMediaType(contentType); err == nil && mediaType == TypeMultipart {
+		file, err := readMultipartFile(body, params["boundary"])
+		if err != nil {
+			return nil, err
+		}
+		body = file.Data
+	}
+
+	return ReadAccessLog(bytes.NewReader(body), host, scheme)
+}
+
+// ReadAccessLog reads the access log from the reader.
+// It is used for uploaded logs and logs at a local path.
+func ReadAccessLog(r io.Reader, host, scheme string) ([]Hit, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme {
+	case "":
+		scheme = "https"
+	case "http", "https":
+	default:
+		return nil, errors.New("Scheme must be \"http\" or \"https\".")
+	}
+
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.New("Error occur while reading the gzipped access log.")
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	counts := make(map[string]int) // the key is the URL.
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var lineHost, lineScheme, target, status string
+		if strings.HasPrefix(line, "{") {
+			lineHost, lineScheme, target, status = parseJSONLogLine(line)
+		} else if m := combinedLog.FindStringSubmatch(line); m != nil {
+			lineHost, target, status = m[1], m[2], m[3]
+		}
+
+		if status != "404" || target == "" {
+			continue
+		}
+		if lineHost != "" && !strings.EqualFold(lineHost, host) {
+			continue // Another host.
+		}
+
+		if lineScheme != "http" && lineScheme != "https" {
+			lineScheme = scheme
+		}
+		if u := toHostURL(lineScheme, host, target); u != "" {
+			counts[u]++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("Error occur while reading the access log.")
+	}
+
+	hits := []Hit{}
+	for u, c := range counts {
+		hits = append(hits, Hit{URL: u, Count: c})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Count != hits[j].Count {
+			return hits[i].Count > hits[j].Count
+		}
+		return hits[i].URL < hits[j].URL
+	})
+
+	return hits, nil
+}
+
+// parseJSONLogLine returns the host, the scheme, the request target and the status from a JSON log line.
+// It understands common field names of nginx and Apache JSON log formats.
+func parseJSONLogLine(line string) (string, string, string, string) {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return "", "", "", ""
+	}
+
+	field := func(keys ...string) string {
+		for _, k := range keys {
+			switch v := m[k].(type) {
+			case string:
+				return v
+			case float64:
+				return fmt.Sprintf("%.0f", v)
+			}
+		}
+		return ""
+	}
+
+	target := field("request_uri", "uri", "path", "url")
+	if target == "" {
+		// Like that: "GET /blog/old-post HTTP/1.1"
+		if parts := strings.Fields(field("request")); len(parts) >= 2 {
+			target = parts[1]
+		}
+	}
+	host := field("host", "http_host", "server_name", "vhost")
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i] // remove the port.
+	}
+
+	return host, strings.ToLower(field("scheme")), target, field("status", "status_code")
+}
+
+// toHostURL creates the full URL for the request target, query strings are dropped.
+// The scheme of an absolute-URI target is used instead of the given one.
+func toHostURL(scheme, host, target string) string {
+	u, err := neturl.Parse(target)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	if u.Host != "" && !strings.EqualFold(u.Hostname(), host) {
+		return ""
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + host + u.EscapedPath()
+}
@@ -0,0 +1,72 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+)
+
+const testAccessLog = `127.0.0.1 - - [10/Oct/2020:13:55:36 +0300] "GET /blog/old-post?utm_source=x HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+127.0.0.2 - - [10/Oct/2020:13:55:37 +0300] "GET /blog/old-post HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+127.0.0.3 - - [10/Oct/2020:13:55:38 +0300] "GET /pricing HTTP/1.1" 200 1024 "-" "Mozilla/5.0"
+other.org:443 127.0.0.4 - - [10/Oct/2020:13:55:39 +0300] "GET /other HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+zeo.org:443 127.0.0.5 - - [10/Oct/2020:13:55:40 +0300] "GET /carbon-old HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+{"host": "zeo.org", "request": "GET /blog/old-post HTTP/2.0", "status": 404}
+{"http_host": "zeo.org:443", "uri": "/carbon-old", "status": "404"}
+{"host": "other.org", "uri": "/another", "status": 404}
+not a log line
+`
+
+func TestReadAccessLog(t *testing.T) {
+	expected := []Hit{
+		{URL: "https://zeo.org/blog/old-post", Count: 3},
+		{URL: "https://zeo.org/carbon-old", Count: 2},
+	}
+
+	hits, err := ReadAccessLog(bytes.NewReader([]byte(testAccessLog)), "zeo.org", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Fatal("Error: Access log parsing issue.", hits)
+	}
+
+	// The same log as gzipped.
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	_, _ = gz.Write([]byte(testAccessLog))
+	_ = gz.Close()
+
+	hits, err = ParseAccessLog("application/gzip", b.Bytes(), "zeo.org", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Fatal("Error: Gzipped access log parsing issue.", hits)
+	}
+}
+
+func TestReadAccessLogShouldUseTheScheme(t *testing.T) {
+	log := `127.0.0.1 - - [10/Oct/2020:13:55:36 +0300] "GET /old HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+127.0.0.1 - - [10/Oct/2020:13:55:37 +0300] "GET https://zeo.org/secure HTTP/1.1" 404 153 "-" "Mozilla/5.0"
+{"host": "zeo.org", "scheme": "https", "uri": "/json", "status": 404}
+`
+	expected := []Hit{
+		{URL: "http://zeo.org/old", Count: 1},
+		{URL: "https://zeo.org/json", Count: 1},
+		{URL: "https://zeo.org/secure", Count: 1},
+	}
+
+	hits, err := ReadAccessLog(bytes.NewReader([]byte(log)), "zeo.org", "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Fatal("Error: Scheme is not used.", hits)
+	}
+
+	if _, err := ReadAccessLog(bytes.NewReader([]byte(log)), "zeo.org", "ftp"); err == nil {
+		t.Fatal("Error: Scheme must be validated.")
+	}
+}
@@ -0,0 +1,27 @@
+package input
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+)
+
+// parseCSV parses the CSV body, returns values of the selected column.
+// The delimiter is detected as comma or semicolon.
+func parseCSV(body []byte, opts Options) ([]string, error) {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")) // remove UTF-8 BOM.
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if firstLine := bytes.SplitN(body, []byte("\n"), 2)[0]; bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, errors.New("Error occur while reading the CSV file. Check your file.")
+	}
+
+	return selectColumn(rows, opts)
+}
@@ -0,0 +1,231 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+)
+
+// Content types that are supported as the request body.
+const (
+	TypeJSON      = "application/json"
+	TypeText      = "text/plain"
+	TypeCSV       = "text/csv"
+	TypeXLSX      = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	TypeMultipart = "multipart/form-data"
+)
+
+// SitemapField is the form field of the uploaded sitemap.
+// It is not read as an input file.
+const SitemapField = "sitemap"
+
+// Options keeps the selections for tabular inputs.
+type Options struct {
+	Sheet  string // the sheet name for XLSX, the first sheet is used if it is empty.
+	Column string // the header name, the letter ("B") or the position ("2") of the column.
+	Header bool   // the first row is skipped if it is true.
+}
+
+// jsonBody keeps the JSON request body.
+type jsonBody struct {
+	Values []struct {
+		Value string `json:"value"`
+	} `json:"values"`
+}
+
+// Parse returns the values in the body by evaluating the content type.
+// If the content type is empty, the body is parsed as JSON.
+func Parse(contentType string, body []byte, opts Options) ([]string, error) {
+	mediaType := TypeJSON
+	params := map[string]string{}
+	if contentType != "" {
+		var err error
+		mediaType, params, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, errors.New("Content-Type is not valid.")
+		}
+	}
+
+	switch mediaType {
+	case TypeJSON:
+		return parseJSON(body)
+	case TypeText:
+		return parseText(body), nil
+	case TypeCSV:
+		return parseCSV(body, opts)
+	case TypeXLSX, "application/octet-stream":
+		return parseXLSX(body, opts)
+	case TypeMultipart:
+		return parseMultipart(body, params["boundary"], opts)
+	default:
+		return nil, fmt.Errorf("Content-Type \"%s\" is not supported.", mediaType)
+	}
+}
+
+// parseJSON parses the body that is like that: {"values":[{"value":"..."}]}
+func parseJSON(body []byte) ([]string, error) {
+	var b jsonBody
+	err := json.Unmarshal(body, &b)
+	if err != nil {
+		return nil, errors.New("Error occur while unmarshalling body-json value. Check your request.")
+	}
+
+	r := []string{}
+	for _, v := range b.Values {
+		r = append(r, v.Value)
+	}
+	return r, nil
+}
+
+// parseText parses the body that has one value per line.
+func parseText(body []byte) []string {
+	r := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			r = append(r, line)
+		}
+	}
+	return r
+}
+
+// parseMultipart parses the first file in the form by evaluating its content type or extension.
+func parseMultipart(body []byte, boundary string, opts Options) ([]string, error) {
+	file, err := readMultipartFile(body, boundary)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Name)) {
+	case ".xlsx":
+		return parseXLSX(file.Data, opts)
+	case ".csv":
+		return parseCSV(file.Data, opts)
+	case ".json":
+		return parseJSON(file.Data)
+	default:
+		return Parse(file.ContentType, file.Data, opts)
+	}
+}
+
+// multipartFile keeps a file in a form.
+type multipartFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// readMultipartFile returns the first file in the form.
+func readMultipartFile(body []byte, boundary string) (multipartFile, error) {
+	if boundary == "" {
+		return multipartFile{}, errors.New("Multipart boundary is not set.")
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return multipartFile{}, errors.New("There is no file in the form.")
+		}
+		if part.FileName() == "" || part.FormName() == SitemapField {
+			continue // Not an input file.
+		}
+
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return multipartFile{}, errors.New("Error occur while reading the file.")
+		}
+		return multipartFile{
+			Name:        part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Data:        data,
+		}, nil
+	}
+}
+
+// FormFile returns the file with the given field name in the form.
+// It returns false if the body is not a form or the file doesn't exist.
+func FormFile(contentType string, body []byte, name string) ([]byte, bool, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != TypeMultipart || params["boundary"] == "" {
+		return nil, false, nil
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, false, nil
+		}
+		if part.FormName() != name {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, false, errors.New("Error occur while reading the file.")
+		}
+		return data, true, nil
+	}
+}
+
+// selectColumn returns values of the selected column from the rows.
+func selectColumn(rows [][]string, opts Options) ([]string, error) {
+	if len(rows) == 0 {
+		return []string{}, nil
+	}
+
+	index, isHeader, err := columnIndex(rows[0], opts.Column)
+	if err != nil {
+		return nil, err
+	}
+	if isHeader || opts.Header {
+		rows = rows[1:]
+	}
+
+	r := []string{}
+	for _, row := range rows {
+		if index < len(row) {
+			if v := strings.TrimSpace(row[index]); v != "" {
+				r = append(r, v)
+			}
+		}
+	}
+	return r, nil
+}
+
+// columnIndex returns the zero-based index of the column.
+// It also tells whether the column is selected by the header name.
+func columnIndex(header []string, column string) (int, bool, error) {
+	column = strings.TrimSpace(column)
+	if column == "" {
+		return 0, false, nil
+	}
+
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), column) {
+			return i, true, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(column); err == nil && n > 0 {
+		return n - 1, false, nil
+	}
+
+	if n, err := excelize.ColumnNameToNumber(column); err == nil {
+		return n - 1, false, nil
+	}
+
+	return 0, false, fmt.Errorf("Column \"%s\" is not found.", column)
+}
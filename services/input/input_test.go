@@ -0,0 +1,94 @@
+package input
+
+import (
+	"bytes"
+	"mime/multipart"
+	"reflect"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+)
+
+func TestParseJSON(t *testing.T) {
+	values, err := Parse("", []byte(`{"values": [{"value": "https://tools.zeo.org/carbon"}]}`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://tools.zeo.org/carbon"}) {
+		t.Fatal("Error: JSON parsing issue.", values)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	values, err := Parse("text/plain; charset=utf-8", []byte("https://zeo.org/a\r\n\r\n  https://zeo.org/b  \n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://zeo.org/a", "https://zeo.org/b"}) {
+		t.Fatal("Error: Text parsing issue.", values)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	body := []byte("Clicks;URL\n10;https://zeo.org/a\n5;https://zeo.org/b\n")
+
+	values, err := Parse("text/csv", body, Options{Column: "url"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://zeo.org/a", "https://zeo.org/b"}) {
+		t.Fatal("Error: CSV parsing by header issue.", values)
+	}
+
+	values, err = Parse("text/csv", body, Options{Column: "2", Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://zeo.org/a", "https://zeo.org/b"}) {
+		t.Fatal("Error: CSV parsing by position issue.", values)
+	}
+
+	_, err = Parse("text/csv", body, Options{Column: "Impressions"})
+	if err == nil {
+		t.Fatal("Error: Unknown column is accepted.")
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	f.NewSheet("404s")
+	_ = f.SetCellValue("404s", "A1", "Clicks")
+	_ = f.SetCellValue("404s", "B1", "Page")
+	_ = f.SetCellValue("404s", "B2", "https://zeo.org/a")
+	_ = f.SetCellValue("404s", "B3", "https://zeo.org/b")
+	b, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Parse(TypeXLSX, b.Bytes(), Options{Sheet: "404s", Column: "B", Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://zeo.org/a", "https://zeo.org/b"}) {
+		t.Fatal("Error: XLSX parsing issue.", values)
+	}
+
+	// Upload the same file as a form.
+	form := &bytes.Buffer{}
+	w := multipart.NewWriter(form)
+	part, err := w.CreateFormFile("file", "export.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(b.Bytes())
+	_ = w.Close()
+
+	values, err = Parse(w.FormDataContentType(), form.Bytes(), Options{Sheet: "404s", Column: "Page"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"https://zeo.org/a", "https://zeo.org/b"}) {
+		t.Fatal("Error: Multipart parsing issue.", values)
+	}
+}
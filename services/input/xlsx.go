@@ -0,0 +1,31 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/360EntSecGroup-Skylar/excelize/v2"
+)
+
+// parseXLSX parses the XLSX body, returns values of the selected sheet and column.
+func parseXLSX(body []byte, opts Options) ([]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("Error occur while reading the XLSX file. Check your file.")
+	}
+
+	sheet := opts.Sheet
+	if sheet == "" {
+		sheet = f.GetSheetName(0)
+	} else if f.GetSheetIndex(sheet) == -1 {
+		return nil, fmt.Errorf("Sheet \"%s\" is not found.", sheet)
+	}
+
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, errors.New("Error occur while reading the XLSX file. Check your file.")
+	}
+
+	return selectColumn(rows, opts)
+}
@@ -0,0 +1,272 @@
+package keypool
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNoKey is returned when there is no key that can be used at this moment.
+var ErrNoKey = errors.New("There is no available SERP API key.")
+
+// States of the keys.
+const (
+	StateHealthy   = "healthy"
+	StateCooling   = "cooling"   // the key failed consecutively, it is not used until the cooldown ends.
+	StateExhausted = "exhausted" // the quota of the key is exhausted, it is not used until the quota cooldown ends.
+)
+
+// Key is an address and its API key.
+type Key struct {
+	Address string `json:"address"`
+	Key     string `json:"key"`
+}
+
+// Pool selects keys by using weighted round-robin, healthy and fast keys are selected more.
+// It is shared by the requests of the lambda instance, so the health of the keys is kept between requests.
+type Pool struct {
+	Threshold     int           // consecutive failures to cool down the key.
+	Cooldown      time.Duration // the key is not used during the cooldown after the threshold is reached.
+	QuotaCooldown time.Duration // the key is not used during the quota cooldown after its quota is exhausted.
+
+	mu   sync.Mutex
+	keys []*key
+	now  func() time.Time
+}
+
+// key keeps the state of a key.
+type key struct {
+	Key
+	health    float64       // moving average of the successes, 1 is always successful.
+	latency   time.Duration // moving average of the latencies.
+	current   float64       // current weight of the smooth weighted round-robin.
+	requests  int64
+	failures  int64
+	exhausted int64
+	failing   int // consecutive failures.
+	until     time.Time
+	quota     bool // the cooldown is caused by the quota.
+	lastError string
+}
+
+// Status is a snapshot of a key, the API key is masked.
+type Status struct {
+	Address   string     `json:"address"`
+	Key       string     `json:"key"`
+	State     string     `json:"state"`
+	Weight    float64    `json:"weight"`
+	Requests  int64      `json:"requests"`
+	Failures  int64      `json:"failures"`
+	Exhausted int64      `json:"exhausted"`
+	Failing   int        `json:"failing"`         // consecutive failures.
+	LatencyMs int64      `json:"latencyMs"`       // moving average of the latencies.
+	Until     *time.Time `json:"until,omitempty"` // the end of the cooldown, it is nil for healthy keys.
+	LastError string     `json:"lastError,omitempty"`
+}
+
+// New creates a pool with the defaults, blank keys are skipped.
+func New(keys []Key) *Pool {
+	p := &Pool{
+		Threshold:     3,
+		Cooldown:      time.Minute,
+		QuotaCooldown: time.Hour,
+		now:           time.Now,
+	}
+	for _, k := range keys {
+		if k.Address == "" || k.Key == "" {
+			continue
+		}
+		p.keys = append(p.keys, &key{Key: k, health: 1})
+	}
+	return p
+}
+
+// NewFromEnv creates a pool from SERP_API_CREDENTIALS_JSON, the defaults are overridden by
+// SERP_KEY_FAILURE_THRESHOLD, SERP_KEY_COOLDOWN_SECONDS and SERP_KEY_QUOTA_COOLDOWN_MINUTES.
+//
+// The value must be like that:
+// {"keys":[{"address":"...", "key":"..."}, {...}, {...}]}
+func NewFromEnv() (*Pool, error) {
+	var v struct {
+		Keys []Key `json:"keys"`
+	}
+	err := json.Unmarshal([]byte(os.Getenv("SERP_API_CREDENTIALS_JSON")), &v)
+	if err != nil {
+		return nil, errors.New("Unable to parse SERP API credentials.")
+	}
+
+	p := New(v.Keys)
+	if len(p.keys) == 0 {
+		return nil, errors.New("There is no SERP API key.")
+	}
+	if n, err := strconv.Atoi(os.Getenv("SERP_KEY_FAILURE_THRESHOLD")); err == nil && n > 0 {
+		p.Threshold = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("SERP_KEY_COOLDOWN_SECONDS")); err == nil && n > 0 {
+		p.Cooldown = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("SERP_KEY_QUOTA_COOLDOWN_MINUTES")); err == nil && n > 0 {
+		p.QuotaCooldown = time.Duration(n) * time.Minute
+	}
+	return p, nil
+}
+
+var (
+	defaultPool    *Pool
+	defaultPoolErr error
+	defaultOnce    sync.Once
+)
+
+// Default returns the pool that is defined at the env.
+// The pool is created only once for the lambda instance, so the env is parsed only once.
+func Default() (*Pool, error) {
+	defaultOnce.Do(func() {
+		defaultPool, defaultPoolErr = NewFromEnv()
+	})
+	return defaultPool, defaultPoolErr
+}
+
+// Next returns the index and the key that should be used, keys that are tried are skipped.
+// Keys that are cooling down or exhausted are not used, ErrNoKey is returned if there is no other key.
+func (p *Pool) Next(tried map[int]bool) (int, Key, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	selected, total := -1, 0.0
+	for i, k := range p.keys {
+		if tried[i] || now.Before(k.until) {
+			continue
+		}
+		w := weight(k)
+		k.current += w
+		total += w
+		if selected == -1 || k.current > p.keys[selected].current {
+			selected = i
+		}
+	}
+	if selected == -1 {
+		return -1, Key{}, ErrNoKey
+	}
+
+	p.keys[selected].current -= total
+	return selected, p.keys[selected].Key, nil
+}
+
+// Success records a successful request of the key.
+func (p *Pool) Success(i int, latency time.Duration) {
+	p.update(i, func(k *key, now time.Time) {
+		k.requests++
+		k.health = average(k.health, 1)
+		k.latency = averageLatency(k.latency, latency)
+		k.failing = 0
+		k.until, k.quota = time.Time{}, false
+	})
+}
+
+// Failure records a failed request of the key, the key is cooled down after consecutive failures.
+// The consecutive failures are kept after the cooldown, so the key is cooled down again by its next failure.
+func (p *Pool) Failure(i int, latency time.Duration, err error) {
+	p.update(i, func(k *key, now time.Time) {
+		k.requests++
+		k.failures++
+		k.health = average(k.health, 0)
+		k.latency = averageLatency(k.latency, latency)
+		k.failing++
+		if err != nil {
+			k.lastError = err.Error()
+		}
+		if k.failing >= p.Threshold {
+			k.until, k.quota = now.Add(p.Cooldown), false
+		}
+	})
+}
+
+// Exhausted records that the quota of the key is exhausted, the key is not used until the wait ends.
+// The quota cooldown is used if the wait is not positive.
+func (p *Pool) Exhausted(i int, wait time.Duration, err error) {
+	p.update(i, func(k *key, now time.Time) {
+		k.requests++
+		k.exhausted++
+		if err != nil {
+			k.lastError = err.Error()
+		}
+		if wait <= 0 {
+			wait = p.QuotaCooldown
+		}
+		k.until, k.quota = now.Add(wait), true
+	})
+}
+
+// Status returns the snapshot of the keys in the order of the env.
+func (p *Pool) Status() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	r := make([]Status, 0, len(p.keys))
+	for _, k := range p.keys {
+		s := Status{
+			Address:   k.Address,
+			Key:       mask(k.Key.Key),
+			State:     StateHealthy,
+			Weight:    weight(k),
+			Requests:  k.requests,
+			Failures:  k.failures,
+			Exhausted: k.exhausted,
+			Failing:   k.failing,
+			LatencyMs: k.latency.Milliseconds(),
+			LastError: k.lastError,
+		}
+		if now.Before(k.until) {
+			until := k.until
+			s.State, s.Until = StateCooling, &until
+			if k.quota {
+				s.State = StateExhausted
+			}
+		}
+		r = append(r, s)
+	}
+	return r
+}
+
+// update changes the state of the key, unknown indexes are ignored.
+func (p *Pool) update(i int, f func(k *key, now time.Time)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if i < 0 || i >= len(p.keys) {
+		return
+	}
+	f(p.keys[i], p.now())
+}
+
+// weight returns the weight of the key, it is decreased by the failures and the latency.
+// It is never zero, so a key that is recovered from the cooldown can be selected again.
+func weight(k *key) float64 {
+	return 0.05 + k.health/(1+k.latency.Seconds())
+}
+
+// average returns the exponentially weighted moving average.
+func average(prev, v float64) float64 {
+	return 0.8*prev + 0.2*v
+}
+
+// averageLatency returns the moving average of the latencies, the first latency is taken as it is.
+func averageLatency(prev, v time.Duration) time.Duration {
+	if prev == 0 {
+		return v
+	}
+	return time.Duration(average(float64(prev), float64(v)))
+}
+
+// mask hides the API key except its last 4 characters.
+func mask(s string) string {
+	if len(s) <= 4 {
+		return "****"
+	}
+	return "****" + s[len(s)-4:]
+}
@@ -0,0 +1,110 @@
+package keypool
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestPool returns a pool with a fake clock.
+func newTestPool(keys ...string) (*Pool, *time.Time) {
+	list := []Key{}
+	for _, k := range keys {
+		list = append(list, Key{Address: "https://serp.example.com", Key: k})
+	}
+	p := New(list)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	return p, &now
+}
+
+func TestNewFromEnvShouldSkipBlankKeys(t *testing.T) {
+	os.Setenv("SERP_API_CREDENTIALS_JSON", `{"keys":[{"address":"https://serp.example.com","key":""},{"address":"https://serp.example.com","key":"key-2"}]}`)
+	defer os.Unsetenv("SERP_API_CREDENTIALS_JSON")
+
+	p, err := NewFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Status(); len(s) != 1 || s[0].Key != "****ey-2" {
+		t.Fatal("Error: Blank keys are not skipped.", s)
+	}
+}
+
+func TestNextShouldPreferHealthyKeys(t *testing.T) {
+	p, _ := newTestPool("key-1", "key-2")
+	p.Failure(0, time.Second, errors.New("fail"))
+	p.Success(1, 100*time.Millisecond)
+
+	counts := map[int]int{}
+	for i := 0; i < 100; i++ {
+		selected, _, err := p.Next(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[selected]++
+	}
+	if counts[0] == 0 || counts[1] <= 2*counts[0] {
+		t.Fatal("Error: Healthy key is not preferred.", counts)
+	}
+}
+
+func TestNextShouldSkipTriedKeys(t *testing.T) {
+	p, _ := newTestPool("key-1", "key-2")
+
+	selected, _, _ := p.Next(nil)
+	next, _, err := p.Next(map[int]bool{selected: true})
+	if err != nil || next == selected {
+		t.Fatal("Error: Tried key is selected again.", selected, next, err)
+	}
+	if _, _, err := p.Next(map[int]bool{0: true, 1: true}); err != ErrNoKey {
+		t.Fatal("Error: ErrNoKey is expected.", err)
+	}
+}
+
+func TestFailuresShouldCoolDownTheKey(t *testing.T) {
+	p, now := newTestPool("key-1")
+	p.Threshold, p.Cooldown = 2, time.Minute
+
+	p.Failure(0, 0, errors.New("fail"))
+	if _, _, err := p.Next(nil); err != nil {
+		t.Fatal("Error: Key is cooled down before the threshold.", err)
+	}
+	p.Failure(0, 0, errors.New("fail"))
+	if _, _, err := p.Next(nil); err != ErrNoKey {
+		t.Fatal("Error: Key is not cooled down.", err)
+	}
+	if s := p.Status()[0]; s.State != StateCooling || s.LastError != "fail" {
+		t.Fatal("Error: Status is not valid.", s)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, _, err := p.Next(nil); err != nil {
+		t.Fatal("Error: Key is not used after the cooldown.", err)
+	}
+	p.Success(0, 0)
+	if s := p.Status()[0]; s.State != StateHealthy || s.Failing != 0 {
+		t.Fatal("Error: Key is not recovered.", s)
+	}
+}
+
+func TestExhaustedShouldSkipTheKeyUntilTheQuotaCooldown(t *testing.T) {
+	p, now := newTestPool("key-1", "key-2")
+	p.QuotaCooldown = time.Hour
+	p.Exhausted(0, 0, errors.New("quota"))
+
+	for i := 0; i < 10; i++ {
+		if selected, _, _ := p.Next(nil); selected != 1 {
+			t.Fatal("Error: Exhausted key is selected.")
+		}
+	}
+	if s := p.Status()[0]; s.State != StateExhausted || s.Until == nil || !s.Until.Equal(now.Add(time.Hour)) {
+		t.Fatal("Error: Status is not valid.", s)
+	}
+
+	*now = now.Add(time.Hour)
+	if s := p.Status()[0]; s.State != StateHealthy {
+		t.Fatal("Error: Key is not available after the quota cooldown.", s)
+	}
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Statuses of the jobs.
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Payload tells the result of a job.
+type Payload struct {
+	Status   string         `json:"status"` // StatusCompleted or StatusFailed.
+	Type     string         `json:"type"`
+	Format   string         `json:"format"`
+	Country  string         `json:"country"`
+	Language string         `json:"language"`
+	Counts   map[string]int `json:"counts,omitempty"` // like "total", "success", "fail" and "skipped".
+	URL      string         `json:"url,omitempty"`    // the link of the sheet or the uploaded file.
+	Error    string         `json:"error,omitempty"`
+	Date     time.Time      `json:"date"`
+}
+
+// Notifier sends the payload when a job finishes.
+// It stops when the context is done, so the notification can't outlive the request.
+type Notifier interface {
+	Notify(ctx context.Context, p Payload) error
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier sends the payload as a plain text email.
+type SMTPNotifier struct {
+	Addr     string // like "smtp.example.com:587".
+	Username string
+	Password string
+	From     string
+	To       []string
+
+	send func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifierFromEnv creates an SMTPNotifier for the addresses by using the env file.
+func NewSMTPNotifierFromEnv(to []string) (*SMTPNotifier, error) {
+	if os.Getenv("SMTP_ADDR") == "" || os.Getenv("SMTP_FROM") == "" {
+		return nil, fmt.Errorf("Email notifications are not configured.")
+	}
+	return &SMTPNotifier{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		To:       to,
+	}, nil
+}
+
+// Notify sends the email to the addresses, the connection is upgraded to TLS if the server supports it.
+// The connection is closed when the deadline of the context is reached.
+func (n *SMTPNotifier) Notify(ctx context.Context, p Payload) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, _ := net.SplitHostPort(n.Addr)
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	send := n.send
+	if send == nil {
+		send = sendMail
+	}
+	err := send(ctx, n.Addr, auth, n.From, n.To, n.message(p))
+	if err != nil {
+		return fmt.Errorf("Error occur while sending the email: %w", err)
+	}
+	return nil
+}
+
+// sendMail works like smtp.SendMail, but the connection uses the context.
+func sendMail(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		err = c.Auth(a)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.Mail(from)
+	if err != nil {
+		return err
+	}
+	for _, address := range to {
+		err = c.Rcpt(address)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns the email with its headers.
+func (n *SMTPNotifier) message(p Payload) []byte {
+	subject := fmt.Sprintf("Carbon %s %s-%s result is %s", p.Type, p.Country, p.Language, p.Status)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Status: %s\r\n", p.Status)
+	fmt.Fprintf(&b, "Type: %s\r\n", p.Type)
+	fmt.Fprintf(&b, "Format: %s\r\n", p.Format)
+	fmt.Fprintf(&b, "Country: %s\r\n", p.Country)
+	fmt.Fprintf(&b, "Language: %s\r\n", p.Language)
+	fmt.Fprintf(&b, "Date: %s\r\n", p.Date.UTC().Format("2006-01-02 15:04"))
+
+	names := []string{}
+	for name := range p.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %d\r\n", strings.Title(name), p.Counts[name])
+	}
+
+	if p.URL != "" {
+		fmt.Fprintf(&b, "\r\nResult: %s\r\n", p.URL)
+	}
+	if p.Error != "" {
+		fmt.Fprintf(&b, "\r\nError: %s\r\n", p.Error)
+	}
+	return []byte(b.String())
+}
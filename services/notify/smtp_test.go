@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPNotifier(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	n := &SMTPNotifier{
+		Addr:     "smtp.example.com:587",
+		Username: "carbon",
+		Password: "secret",
+		From:     "carbon@zeo.org",
+		To:       []string{"bora@zeo.org", "client@example.com"},
+		send: func(_ context.Context, a string, _ smtp.Auth, f string, t []string, m []byte) error {
+			addr, from, to, msg = a, f, t, m
+			return nil
+		},
+	}
+
+	err := n.Notify(context.Background(), Payload{
+		Status:   StatusCompleted,
+		Type:     "url",
+		Country:  "tr",
+		Language: "tr",
+		Counts:   map[string]int{"total": 3, "fail": 1},
+		URL:      "https://docs.google.com/spreadsheets/d/x",
+		Date:     time.Date(2020, 12, 1, 15, 4, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != n.Addr || from != n.From || len(to) != 2 {
+		t.Fatal("Error: Email is not sent to the addresses.", addr, from, to)
+	}
+	for _, line := range []string{
+		"To: bora@zeo.org, client@example.com\r\n",
+		"Subject: Carbon url tr-tr result is completed\r\n",
+		"Fail: 1\r\nTotal: 3\r\n",
+		"Result: https://docs.google.com/spreadsheets/d/x\r\n",
+	} {
+		if !strings.Contains(string(msg), line) {
+			t.Fatal("Error: Email doesn't have the line.", line, string(msg))
+		}
+	}
+}
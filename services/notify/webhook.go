@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/zeoagency/carbon/services/publicnet"
+)
+
+// errPrivateAddress is returned for callbacks to addresses that are not public.
+var errPrivateAddress = errors.New("Callback URL must not be in a private network.")
+
+// WebhookNotifier posts the payload as JSON to the callback URL.
+// Requests are signed if the secret is set, failed ones are retried with backoff.
+type WebhookNotifier struct {
+	URL     string
+	Secret  string
+	Retries int           // retries after the first attempt.
+	Backoff time.Duration // waiting time before the first retry, it is doubled for the next ones.
+	Client  *http.Client
+}
+
+// NewWebhookNotifierFromEnv creates a WebhookNotifier for the URL by using the env file.
+func NewWebhookNotifierFromEnv(url string) *WebhookNotifier {
+	retries, err := strconv.Atoi(os.Getenv("CALLBACK_RETRIES"))
+	if err != nil || retries < 0 {
+		retries = 3
+	}
+	return &WebhookNotifier{
+		URL:     url,
+		Secret:  os.Getenv("CALLBACK_SECRET"),
+		Retries: retries,
+		Backoff: time.Second,
+		Client:  &http.Client{Timeout: 10 * time.Second, Transport: publicTransport()},
+	}
+}
+
+// CheckCallbackURL returns an error if the URL is not HTTP or HTTPS, or its host resolves to an address that is not public.
+// Addresses are checked again while dialing, see publicTransport.
+// Private addresses are allowed if CALLBACK_ALLOW_PRIVATE is "true", for local development.
+func CheckCallbackURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("Callback URL must be an HTTP or HTTPS URL.")
+	}
+	if allowPrivate() {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("Callback URL host \"%s\" is not found.", u.Hostname())
+	}
+	for _, a := range addrs {
+		if !publicnet.IsPublic(a.IP) {
+			return errPrivateAddress
+		}
+	}
+	return nil
+}
+
+// publicTransport returns a transport that only dials public addresses, unless CALLBACK_ALLOW_PRIVATE is "true".
+// The address is checked after the DNS lookup, so the host can't resolve to a private one after CheckCallbackURL.
+func publicTransport() http.RoundTripper {
+	if allowPrivate() {
+		return http.DefaultTransport
+	}
+	return publicnet.Transport()
+}
+
+// allowPrivate tells whether callbacks can reach private addresses.
+func allowPrivate() bool {
+	return os.Getenv("CALLBACK_ALLOW_PRIVATE") == "true"
+}
+
+// Notify posts the payload, it is retried for network errors, 429 and 5xx responses.
+// Retries are stopped if the context is done, or its deadline is before the next attempt.
+func (n *WebhookNotifier) Notify(ctx context.Context, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.Retries {
+			return fmt.Errorf("Error occur while calling the callback URL: %w", err)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return fmt.Errorf("Error occur while calling the callback URL: %w", err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("Error occur while calling the callback URL: %w", err)
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the body once, and tells whether it can be retried if it fails.
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Carbon")
+	if n.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Carbon-Timestamp", timestamp)
+		req.Header.Set("X-Carbon-Signature", "sha256="+Sign(n.Secret, timestamp, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if errors.Is(err, publicnet.ErrPrivateAddress) {
+		return false, errPrivateAddress
+	}
+	if err != nil {
+		return true, err
+	}
+	res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return true, fmt.Errorf("%s", res.Status)
+	default:
+		return false, fmt.Errorf("%s", res.Status)
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" with the secret.
+// Receivers must compare it with X-Carbon-Signature, and reject old timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
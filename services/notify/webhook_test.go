@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifierShouldSignAndRetry(t *testing.T) {
+	attempts := 0
+	var payload Payload
+	var signed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		signed = r.Header.Get("X-Carbon-Signature") == "sha256="+Sign("secret", r.Header.Get("X-Carbon-Timestamp"), body)
+		json.Unmarshal(body, &payload)
+	}))
+	defer server.Close()
+
+	n := &WebhookNotifier{URL: server.URL, Secret: "secret", Retries: 2, Backoff: time.Millisecond, Client: server.Client()}
+	err := n.Notify(context.Background(), Payload{Status: StatusCompleted, Type: "url", Counts: map[string]int{"total": 2}, URL: "https://docs.google.com/spreadsheets/d/x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 || !signed {
+		t.Fatal("Error: Callback is not retried or signed.", attempts, signed)
+	}
+	if payload.Status != StatusCompleted || payload.Counts["total"] != 2 || payload.URL == "" {
+		t.Fatal("Error: Payload is not valid.", payload)
+	}
+}
+
+func TestWebhookNotifierShouldNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	n := &WebhookNotifier{URL: server.URL, Retries: 3, Backoff: time.Millisecond, Client: server.Client()}
+	if err := n.Notify(context.Background(), Payload{Status: StatusFailed}); err == nil || attempts != 1 {
+		t.Fatal("Error: Client errors must fail without retries.", attempts, err)
+	}
+}
+
+func TestWebhookNotifierShouldStopRetryingBeforeTheDeadline(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	n := &WebhookNotifier{URL: server.URL, Retries: 3, Backoff: time.Second, Client: server.Client()}
+	if err := n.Notify(ctx, Payload{Status: StatusFailed}); err == nil || attempts != 1 || time.Since(start) > time.Second {
+		t.Fatal("Error: Retries must not wait after the deadline.", attempts, time.Since(start), err)
+	}
+}
+
+func TestCheckCallbackURLShouldRejectPrivateAddresses(t *testing.T) {
+	for _, u := range []string{
+		"http://127.0.0.1/cb",
+		"http://localhost:8080/cb",
+		"http://10.0.0.1/cb",
+		"http://192.168.1.1/cb",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/cb",
+		"http://[fd00::1]/cb",
+		"http://0.0.0.0/cb",
+	} {
+		if err := CheckCallbackURL(context.Background(), u); err != errPrivateAddress {
+			t.Fatal("Error: Private addresses must be rejected.", u, err)
+		}
+	}
+	if err := CheckCallbackURL(context.Background(), "ftp://example.com"); err == nil {
+		t.Fatal("Error: Only HTTP and HTTPS URLs must be allowed.")
+	}
+	if err := CheckCallbackURL(context.Background(), "https://93.184.216.34/cb"); err != nil {
+		t.Fatal("Error: Public addresses must be allowed.", err)
+	}
+}
+
+func TestWebhookNotifierShouldNotDialPrivateAddresses(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifierFromEnv(server.URL)
+	n.Backoff = time.Millisecond
+	if err := n.Notify(context.Background(), Payload{Status: StatusCompleted}); !errors.Is(err, errPrivateAddress) || attempts != 0 {
+		t.Fatal("Error: Private addresses must not be dialed.", attempts, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1600000000.{}' | openssl dgst -sha256 -hmac secret
+	if Sign("secret", "1600000000", []byte("{}")) != "1e56a11da123b137c26fa37b7c222060bdf22988aa9b3248c31244f8b2ef4a28" {
+		t.Fatal("Error: Signature is not valid.", Sign("secret", "1600000000", []byte("{}")))
+	}
+}
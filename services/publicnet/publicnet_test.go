@@ -0,0 +1,19 @@
+package publicnet
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "224.0.0.1"} {
+		if IsPublic(net.ParseIP(ip)) {
+			t.Fatal("Error: Private address is public.", ip)
+		}
+	}
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		if !IsPublic(net.ParseIP(ip)) {
+			t.Fatal("Error: Public address is private.", ip)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery is the interval of removing idle buckets from the memory store.
+const sweepEvery = time.Minute
+
+// bucket keeps the state of a token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // the bucket is full again at this time, so it can be removed.
+}
+
+// memoryStore keeps buckets in the memory.
+// It is only shared by requests that hit the same lambda instance.
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket // the key is the limited key.
+	swept   time.Time
+}
+
+// NewMemoryStore inits the memory store to use.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Take takes one token from the bucket of the key.
+// Buckets that are full again are removed, a missing bucket is the same as a full one.
+func (s *memoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, b.last, now, limit)
+	b.last = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(fullIn(b.tokens, limit))
+
+	if !allowed {
+		return false, waitFor(b.tokens, limit), nil
+	}
+	return true, 0, nil
+}
+
+// sweep removes the buckets that are full again, it runs once in sweepEvery.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepEvery {
+		return
+	}
+	s.swept = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
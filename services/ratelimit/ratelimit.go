@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Limit defines a token bucket.
+// Rate is the count of tokens that are added per minute,
+// Burst is the maximum count of tokens that the bucket can keep.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store is implemented by all bucket backends.
+//
+// Take takes one token from the bucket of the key.
+// If there is no token, it returns false with the duration to wait for the next token.
+type Store interface {
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration, error)
+}
+
+// Limiter limits requests by using separated limits for accounts and anonymous users.
+type Limiter struct {
+	Store     Store
+	Account   Limit
+	Anonymous Limit
+	Login     Limit // login attempts of an IP, they are limited before the password is checked.
+}
+
+// NewLimiterFromEnv creates the limiter that is defined at the env.
+//
+// RATE_LIMIT_STORE is "memory" (default), "redis" or "sqlite".
+// RATE_LIMIT_REDIS_URL is the Redis URL for the "redis" store, like "redis://:password@host:6379/0".
+// RATE_LIMIT_STORE_PATH is the SQLite database for the "sqlite" store.
+// RATE_LIMIT_ACCOUNT_RATE, RATE_LIMIT_ACCOUNT_BURST,
+// RATE_LIMIT_ANONYMOUS_RATE, RATE_LIMIT_ANONYMOUS_BURST,
+// RATE_LIMIT_LOGIN_RATE and RATE_LIMIT_LOGIN_BURST set the limits.
+// A rate of "0" disables the limit.
+func NewLimiterFromEnv() (*Limiter, error) {
+	l := &Limiter{
+		Account:   Limit{Rate: envFloat("RATE_LIMIT_ACCOUNT_RATE", 60), Burst: int(envFloat("RATE_LIMIT_ACCOUNT_BURST", 60))},
+		Anonymous: Limit{Rate: envFloat("RATE_LIMIT_ANONYMOUS_RATE", 5), Burst: int(envFloat("RATE_LIMIT_ANONYMOUS_BURST", 10))},
+		Login:     Limit{Rate: envFloat("RATE_LIMIT_LOGIN_RATE", 60), Burst: int(envFloat("RATE_LIMIT_LOGIN_BURST", 60))},
+	}
+
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+		l.Store = NewMemoryStore()
+	case "redis":
+		s, err := NewRedisStore(os.Getenv("RATE_LIMIT_REDIS_URL"))
+		if err != nil {
+			return nil, err
+		}
+		l.Store = s
+	case "sqlite":
+		s, err := NewSQLiteStore(os.Getenv("RATE_LIMIT_STORE_PATH"))
+		if err != nil {
+			return nil, err
+		}
+		l.Store = s
+	default:
+		return nil, fmt.Errorf("Rate limit store \"%s\" is not supported.", os.Getenv("RATE_LIMIT_STORE"))
+	}
+
+	return l, nil
+}
+
+// AllowAccount takes a token for the account.
+func (l *Limiter) AllowAccount(name string) (bool, time.Duration, error) {
+	return l.allow("account:"+name, l.Account)
+}
+
+// AllowIP takes a token for the anonymous user.
+func (l *Limiter) AllowIP(ip string) (bool, time.Duration, error) {
+	return l.allow("ip:"+ip, l.Anonymous)
+}
+
+// AllowLogin takes a token for a login attempt of the IP.
+func (l *Limiter) AllowLogin(ip string) (bool, time.Duration, error) {
+	return l.allow("login:"+ip, l.Login)
+}
+
+// allow takes a token from the bucket, it always allows if the limit is disabled.
+func (l *Limiter) allow(key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Rate <= 0 {
+		return true, 0, nil
+	}
+	return l.Store.Take(key, limit, time.Now())
+}
+
+// refill returns the token count of the bucket at the given time.
+func refill(tokens float64, last, now time.Time, limit Limit) float64 {
+	elapsed := now.Sub(last).Minutes()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+}
+
+// waitFor returns the duration to have one token.
+func waitFor(tokens float64, limit Limit) time.Duration {
+	missing := 1 - tokens
+	return time.Duration(math.Ceil(missing / limit.Rate * float64(time.Minute)))
+}
+
+// fullIn returns the duration to fill the bucket.
+func fullIn(tokens float64, limit Limit) time.Duration {
+	missing := float64(limit.Burst) - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(missing / limit.Rate * float64(time.Minute)))
+}
+
+// envFloat returns the env value as float, returns the default value if it is not set.
+func envFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
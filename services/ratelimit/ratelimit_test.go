@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newFakeRedis starts a server that talks RESP, it answers EVAL of the take script with the refill of the other stores.
+// It returns the URL of the server. The script itself is run in redis_test.go.
+func newFakeRedis(t *testing.T, password string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	buckets := map[string][2]float64{} // the tokens and the last time in milliseconds.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					v, err := readReply(r)
+					if err != nil {
+						return
+					}
+					args := []string{}
+					for _, a := range v.([]interface{}) {
+						args = append(args, a.(string))
+					}
+
+					switch {
+					case args[0] == "AUTH" && args[len(args)-1] == password:
+						authed = true
+						fmt.Fprint(conn, "+OK\r\n")
+					case !authed:
+						fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+					case args[0] == "EVAL" && args[1] == takeScript:
+						rate, _ := strconv.ParseFloat(args[4], 64)
+						burst, _ := strconv.ParseFloat(args[5], 64)
+						now, _ := strconv.ParseFloat(args[6], 64)
+
+						mu.Lock()
+						b, ok := buckets[args[3]]
+						if !ok {
+							b = [2]float64{burst, now}
+						}
+						limit := Limit{Rate: rate, Burst: int(burst)}
+						tokens := refill(b[0], time.Unix(0, int64(b[1])*int64(time.Millisecond)), time.Unix(0, int64(now)*int64(time.Millisecond)), limit)
+						allowed := 0
+						if tokens >= 1 {
+							tokens, allowed = tokens-1, 1
+						}
+						buckets[args[3]] = [2]float64{tokens, now}
+						mu.Unlock()
+
+						text := strconv.FormatFloat(tokens, 'f', -1, 64)
+						fmt.Fprintf(conn, "*2\r\n:%d\r\n$%d\r\n%s\r\n", allowed, len(text), text)
+					default:
+						fmt.Fprint(conn, "-ERR unknown command\r\n")
+					}
+				}
+			}()
+		}
+	}()
+	return "redis://:" + password + "@" + ln.Addr().String()
+}
+
+func TestStores(t *testing.T) {
+	sqliteStore, err := NewSQLiteStore(filepath.Join(t.TempDir(), "buckets.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redisStore, err := NewRedisStore(newFakeRedis(t, "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limit := Limit{Rate: 60, Burst: 2} // a token per second.
+	now := time.Now()
+
+	for _, s := range []Store{NewMemoryStore(), sqliteStore, redisStore} {
+		for i := 0; i < limit.Burst; i++ {
+			ok, _, err := s.Take("ip:1.1.1.1", limit, now)
+			if err != nil || !ok {
+				t.Fatal("Error: Burst is not allowed.", err)
+			}
+		}
+
+		ok, wait, err := s.Take("ip:1.1.1.1", limit, now)
+		if err != nil || ok {
+			t.Fatal("Error: Empty bucket is allowed.", err)
+		}
+		if wait <= 0 || wait > time.Second {
+			t.Fatalf("Error: Wait duration is not valid: %s", wait)
+		}
+
+		// Other keys have their own buckets.
+		ok, _, _ = s.Take("ip:2.2.2.2", limit, now)
+		if !ok {
+			t.Fatal("Error: Buckets are shared between keys.")
+		}
+
+		// The bucket is refilled after a second.
+		ok, _, _ = s.Take("ip:1.1.1.1", limit, now.Add(time.Second))
+		if !ok {
+			t.Fatal("Error: Bucket is not refilled.")
+		}
+	}
+}
+
+func TestDisabledLimit(t *testing.T) {
+	l := &Limiter{Store: NewMemoryStore(), Anonymous: Limit{Rate: 0}}
+	for i := 0; i < 100; i++ {
+		ok, _, _ := l.AllowIP("1.1.1.1")
+		if !ok {
+			t.Fatal("Error: Disabled limit is applied.")
+		}
+	}
+}
+
+func TestMemoryStoreShouldRemoveFullBuckets(t *testing.T) {
+	s := NewMemoryStore().(*memoryStore)
+	limit := Limit{Rate: 60, Burst: 2} // a token per second.
+	now := time.Now()
+
+	s.Take("ip:1.1.1.1", limit, now)
+	s.Take("ip:2.2.2.2", limit, now.Add(sweepEvery))
+	if _, ok := s.buckets["ip:1.1.1.1"]; ok {
+		t.Fatal("Error: Full bucket is not removed.")
+	}
+	if _, ok := s.buckets["ip:2.2.2.2"]; !ok {
+		t.Fatal("Error: Bucket is removed before it is full.")
+	}
+}
@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// takeScript takes a token from the bucket of KEYS[1] atomically.
+// ARGV is the rate per minute, the burst and the time in milliseconds.
+// The bucket expires when it is full again, a missing bucket is the same as a full one.
+const takeScript = `
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local tokens, last = tonumber(b[1]), tonumber(b[2])
+if tokens == nil or last == nil then
+	tokens, last = burst, now
+end
+tokens = math.min(burst, tokens + math.max(0, now - last) / 60000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 60000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// redisStore keeps buckets in Redis, so they are shared by all instances.
+// It talks RESP with a single connection, the connection is opened again after an error.
+type redisStore struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedisStore creates a store for the Redis URL, like "redis://:password@host:6379/0".
+// The user name is sent with the password if it is set, for Redis ACL users.
+// Use "rediss://" for TLS.
+func NewRedisStore(rawURL string) (Store, error) {
+	if rawURL == "" {
+		return nil, errors.New("Rate limit Redis URL is not set.")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, errors.New("Rate limit Redis URL is not valid.")
+	}
+
+	s := &redisStore{addr: u.Host, useTLS: u.Scheme == "rediss", timeout: 2 * time.Second}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if p, ok := u.User.Password(); ok {
+		s.username, s.password = u.User.Username(), p
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		s.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, errors.New("Rate limit Redis database is not valid.")
+		}
+	}
+	return s, nil
+}
+
+// Take takes one token from the bucket of the key.
+func (s *redisStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	r, err := s.do("EVAL", takeScript, "1", "carbon:ratelimit:"+key,
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		strconv.Itoa(limit.Burst),
+		strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+	)
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := r.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errors.New("Unexpected Redis response.")
+	}
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return false, 0, errors.New("Unexpected Redis response.")
+	}
+
+	if allowed != 1 {
+		return false, waitFor(tokens, limit), nil
+	}
+	return true, 0, nil
+}
+
+// do sends the command and returns its reply, the connection is closed if there is an error.
+func (s *redisStore) do(args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		err := s.connect()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r, err := s.send(args...)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return nil, fmt.Errorf("Error occur while talking with Redis: %w", err)
+	}
+	return r, nil
+}
+
+// connect opens the connection, authenticates and selects the database.
+func (s *redisStore) connect() error {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	var conn net.Conn
+	var err error
+	if s.useTLS {
+		host, _, _ := net.SplitHostPort(s.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("Error occur while connecting to Redis: %w", err)
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+
+	commands := [][]string{}
+	switch {
+	case s.username != "":
+		commands = append(commands, []string{"AUTH", s.username, s.password})
+	case s.password != "":
+		commands = append(commands, []string{"AUTH", s.password})
+	}
+	if s.db != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	for _, c := range commands {
+		if _, err := s.send(c...); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("Error occur while connecting to Redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// send writes the command as a RESP array, and reads the reply.
+func (s *redisStore) send(args ...string) (interface{}, error) {
+	s.conn.SetDeadline(time.Now().Add(s.timeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(s.r)
+}
+
+// readReply reads a RESP reply. Integers are int64, bulk and simple strings are string, arrays are []interface{}.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			values[i], err = readReply(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newRedisTestStore returns a store for RATE_LIMIT_TEST_REDIS_URL, or for a miniredis server if it is not set.
+// Miniredis runs the take script with its own Lua, set the URL to run the tests with a real Redis.
+func newRedisTestStore(t *testing.T) *redisStore {
+	rawURL := os.Getenv("RATE_LIMIT_TEST_REDIS_URL")
+	if rawURL == "" {
+		m, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(m.Close)
+		m.RequireAuth("secret")
+		rawURL = "redis://:secret@" + m.Addr()
+	}
+
+	s, err := NewRedisStore(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*redisStore)
+}
+
+func TestRedisStoreShouldRunTheTakeScript(t *testing.T) {
+	s := newRedisTestStore(t)
+	key := "test:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	bucket := "carbon:ratelimit:" + key
+	t.Cleanup(func() { s.do("DEL", bucket) })
+
+	limit := Limit{Rate: 7, Burst: 3}
+	now := time.Unix(1600000000, 0)
+
+	ok, _, err := s.Take(key, limit, now)
+	if err != nil || !ok {
+		t.Fatal("Error: Full bucket is not allowed.", err)
+	}
+	r, err := s.do("HMGET", bucket, "tokens", "last")
+	if values, _ := r.([]interface{}); err != nil || len(values) != 2 || values[0] != "2" || values[1] != "1600000000000" {
+		t.Fatal("Error: Fields of the bucket are not valid.", r, err)
+	}
+
+	// The bucket expires when it is full again, a second is added.
+	r, err = s.do("PTTL", bucket)
+	if ttl, _ := r.(int64); err != nil || ttl <= 9472 || ttl > 9572 {
+		t.Fatal("Error: Expiry of the bucket is not valid.", r, err)
+	}
+
+	// Refilled tokens have fractions, they must be the same as the other stores.
+	later := now.Add(1234 * time.Millisecond)
+	want := refill(2, now, later, limit) - 1
+	if ok, _, err := s.Take(key, limit, later); err != nil || !ok {
+		t.Fatal("Error: Refilled bucket is not allowed.", err)
+	}
+	r, err = s.do("HMGET", bucket, "tokens", "last")
+	values, _ := r.([]interface{})
+	if err != nil || len(values) != 2 {
+		t.Fatal("Error: Fields of the bucket are not valid.", r, err)
+	}
+	tokens, _ := strconv.ParseFloat(values[0].(string), 64)
+	if math.Abs(tokens-want) > 1e-9 || values[1] != "1600000001234" {
+		t.Fatal("Error: Tokens are not refilled.", values, want)
+	}
+
+	// The bucket is empty after the last token, the wait is for the missing part of a token.
+	s.Take(key, limit, later)
+	ok, wait, err := s.Take(key, limit, later)
+	if err != nil || ok {
+		t.Fatal("Error: Empty bucket is allowed.", err)
+	}
+	if expected := waitFor(want-1, limit); wait < expected-time.Millisecond || wait > expected+time.Millisecond {
+		t.Fatalf("Error: Wait duration is not valid: %s, expected %s", wait, expected)
+	}
+}
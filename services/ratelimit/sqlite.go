@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore keeps buckets in a SQLite database, so they are kept after restarts.
+// It is for a single host; Lambda instances have their own file systems, use the Redis store to share buckets.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at the given path.
+func NewSQLiteStore(path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Rate limit database path is not set.")
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS buckets (
+		key    TEXT PRIMARY KEY,
+		tokens REAL NOT NULL,
+		last   INTEGER NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+// Take takes one token from the bucket of the key.
+// The bucket is locked by an immediate transaction, so instances that share the file don't take the same token.
+func (s *sqliteStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `BEGIN IMMEDIATE`)
+	if err != nil {
+		return false, 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(ctx, `ROLLBACK`)
+		}
+	}()
+
+	tokens, last := float64(limit.Burst), now
+	var lastNano int64
+	err = conn.QueryRowContext(ctx, `SELECT tokens, last FROM buckets WHERE key = ?`, key).Scan(&tokens, &lastNano)
+	if err != nil && err != sql.ErrNoRows {
+		return false, 0, err
+	}
+	if err == nil {
+		last = time.Unix(0, lastNano)
+	}
+
+	tokens = refill(tokens, last, now, limit)
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	_, err = conn.ExecContext(ctx,
+		`INSERT INTO buckets (key, tokens, last) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens, last = excluded.last`,
+		key, tokens, now.UnixNano(),
+	)
+	if err != nil {
+		return false, 0, err
+	}
+	_, err = conn.ExecContext(ctx, `COMMIT`)
+	if err != nil {
+		return false, 0, err
+	}
+	committed = true
+
+	if !allowed {
+		return false, waitFor(tokens, limit), nil
+	}
+	return true, 0, nil
+}
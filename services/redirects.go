@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/zeoagency/carbon/helpers"
+	"github.com/zeoagency/carbon/models"
+)
+
+// Warning types for suggestions in the batch.
+const (
+	WarningChain   = "chain"
+	WarningLoop    = "loop"
+	WarningDeadEnd = "dead end"
+)
+
+// ResolveRedirectChains checks suggestions that point to other broken URLs in the same batch.
+//
+// Suggestions are seen as redirects; A → B where B is also an input that redirects to C is a chain,
+// it is collapsed to A → C. If the redirects turn back to a URL in the chain, it is a loop,
+// and the suggestion is kept as it is. If the chain ends with a URL that has no suggestion,
+// it is a dead end. All of them are added as warnings.
+func ResolveRedirectChains(urls *models.URLSet) {
+	// The key is the canonical URL, the value is the Original URL.
+	successes := make(map[string]string)
+	for originalURL := range urls.Successes {
+		if c, err := helpers.CanonicalURL(originalURL); err == nil {
+			successes[c] = originalURL
+		}
+	}
+	fails := make(map[string]bool)
+	for originalURL := range urls.Fails {
+		if c, err := helpers.CanonicalURL(originalURL); err == nil {
+			fails[c] = true
+		}
+	}
+
+	// Use the input order to have the same warning order for the same input.
+	originals := urls.SuccessOrder()
+
+	// Take all suggestions before updating any of them.
+	suggestions := make(map[string]string)
+	for _, originalURL := range originals {
+		suggestions[originalURL] = urls.Successes[originalURL].SuggestedURL
+	}
+
+	for _, originalURL := range originals {
+		path := []string{originalURL}
+		visited := map[string]bool{canonical(originalURL): true}
+		current := suggestions[originalURL]
+		loop := false
+
+		for {
+			next, ok := successes[canonical(current)]
+			if !ok {
+				break // The target is not an input, so it is the final target.
+			}
+			path = append(path, next)
+			if visited[canonical(next)] {
+				loop = true
+				break
+			}
+			visited[canonical(next)] = true
+			current = suggestions[next]
+		}
+
+		switch {
+		case loop:
+			urls.AddWarning(originalURL, WarningLoop, strings.Join(path, " → "))
+		case len(path) > 1:
+			urls.SetSuggestion(originalURL, current)
+			urls.AddWarning(originalURL, WarningChain, strings.Join(append(path, current), " → "))
+		}
+
+		if !loop && fails[canonical(current)] {
+			urls.AddWarning(originalURL, WarningDeadEnd, strings.Join(append(path, current), " → ")+" (broken, no suggestion)")
+		}
+	}
+}
+
+// canonical returns the canonical URL, or the URL itself if it is not valid.
+func canonical(url string) string {
+	c, err := helpers.CanonicalURL(url)
+	if err != nil {
+		return url
+	}
+	return c
+}
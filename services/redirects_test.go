@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/zeoagency/carbon/models"
+)
+
+func TestResolveRedirectChains(t *testing.T) {
+	urlSet := models.NewURLSet()
+	// A → B → C is a chain.
+	urlSet.AddSuccess("https://zeo.org/a", []string{"https://zeo.org/b/"})
+	urlSet.AddSuccess("https://zeo.org/b", []string{"https://zeo.org/c"})
+	// X → Y → X is a loop.
+	urlSet.AddSuccess("https://zeo.org/x", []string{"https://zeo.org/y"})
+	urlSet.AddSuccess("https://zeo.org/y", []string{"https://zeo.org/x"})
+	// D → E where E has no suggestion is a dead end.
+	urlSet.AddSuccess("https://zeo.org/d", []string{"https://zeo.org/e"})
+	urlSet.AddFail("https://zeo.org/e", "We could not find any related URLs.")
+
+	ResolveRedirectChains(urlSet)
+
+	if urlSet.Successes["https://zeo.org/a"].SuggestedURL != "https://zeo.org/c" {
+		t.Fatal("Error: Chain is not collapsed.", urlSet.Successes["https://zeo.org/a"])
+	}
+
+	types := make(map[string]string)
+	for _, w := range urlSet.Warnings {
+		types[w.URL] = w.Type
+	}
+	expected := map[string]string{
+		"https://zeo.org/a": WarningChain,
+		"https://zeo.org/x": WarningLoop,
+		"https://zeo.org/y": WarningLoop,
+		"https://zeo.org/d": WarningDeadEnd,
+	}
+	for u, w := range expected {
+		if types[u] != w {
+			t.Fatalf("Error: Warning issue for %s: %s", u, types[u])
+		}
+	}
+	if len(urlSet.Warnings) != len(expected) {
+		t.Fatal("Error: Unexpected warnings.", urlSet.Warnings)
+	}
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/zeoagency/carbon/services/excel"
+)
+
+// hexColor is used to check colors before they are used in styles.
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// htmlTemplate is a self-contained page, styles are inline and the logo is a data URI.
+// Its parts are executed one by one, so rows of the tables are written while they are read.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"isLink":     isLink,
+	"percentage": percentage,
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; color: #222222; }
+header { display: flex; align-items: center; gap: 16px; padding: 16px 32px; background: {{.Color}}; color: {{.TextColor}}; }
+header img { max-height: 48px; }
+header h1 { margin: 0; font-size: 22px; }
+main { padding: 16px 32px; }
+h2 { margin: 32px 0 8px; font-size: 18px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th { background: {{.Color}}; color: {{.TextColor}}; text-align: left; padding: 6px 10px; }
+td { border-bottom: 1px solid #e7e6e6; padding: 6px 10px; vertical-align: top; white-space: pre-wrap; word-break: break-word; }
+.meta th { background: none; color: inherit; padding-left: 0; }
+.bar { background: #e7e6e6; width: 160px; height: 10px; }
+.bar div { background: {{.Color}}; height: 10px; }
+.highlight { font-weight: bold; }
+.muted { color: #aaaaaa; }
+</style>
+</head>
+<body>
+<header>
+{{if .Logo}}<img src="{{.Logo}}" alt="{{.Brand}}">{{end}}
+<h1>{{if .Brand}}{{.Brand}} · {{end}}{{.Title}}</h1>
+</header>
+<main>
+{{with .Summary}}
+<table class="meta">
+{{range .Meta}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{range .Tables}}{{$table := .}}
+<h2>{{.Title}}</h2>
+<table>
+<tr><th>{{.Title}}</th><th>Count</th><th>Percentage</th><th></th></tr>
+{{range .Counts}}<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{percentage $table .}}</td><td><div class="bar"><div style="width: {{percentage $table .}}"></div></div></td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{end}}
+{{define "tableStart"}}
+<h2>{{.Title}}</h2>
+<table>
+<tr>{{range .Columns}}<th>{{.Title}}</th>{{end}}</tr>
+{{end}}
+{{define "row"}}<tr>{{range .}}<td{{if .Highlight}} class="highlight"{{else if .Muted}} class="muted"{{end}}>{{if isLink .Text}}<a href="{{.Text}}">{{.Text}}</a>{{else}}{{.Text}}{{end}}</td>{{end}}</tr>
+{{end}}
+{{define "tableEnd"}}</table>
+{{end}}
+{{define "foot"}}</main>
+</body>
+</html>
+{{end}}`))
+
+// htmlData is the data of htmlTemplate.
+type htmlData struct {
+	Title     string
+	Brand     string
+	Logo      template.URL
+	Color     template.CSS
+	TextColor template.CSS
+	Summary   *htmlSummary
+}
+
+// htmlSummary is the summary of htmlData.
+type htmlSummary struct {
+	Meta   [][2]string
+	Tables []excel.SummaryTable
+}
+
+// ConvertToHTML creates a HTML report that can be opened without any other file.
+func ConvertToHTML(r excel.Report) (*bytes.Buffer, error) {
+	brand := r.Layout.Brand
+	data := htmlData{
+		Title:     title(r),
+		Brand:     brand.Name,
+		Color:     template.CSS(colorOf(brand.Color, "#000000")),
+		TextColor: template.CSS(colorOf(r.Layout.Styles.Title.Color, "#ffffff")),
+	}
+	if r.Summary != nil {
+		data.Summary = &htmlSummary{Meta: excel.MetaFields(r.Summary.Meta), Tables: r.Summary.Tables}
+	}
+
+	logo, mimeType, err := brand.ReadLogo()
+	if err != nil {
+		return nil, err
+	}
+	if logo != nil {
+		// The logo is read from the layouts, so it is trusted.
+		data.Logo = template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(logo))
+	}
+
+	b := &bytes.Buffer{}
+	err = htmlTemplate.ExecuteTemplate(b, "head", data)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range r.Tables {
+		err = htmlTemplate.ExecuteTemplate(b, "tableStart", t)
+		if err != nil {
+			return nil, err
+		}
+		err = t.EachRow(func(values []excel.Value) error {
+			return htmlTemplate.ExecuteTemplate(b, "row", values)
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = htmlTemplate.ExecuteTemplate(b, "tableEnd", t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = htmlTemplate.ExecuteTemplate(b, "foot", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// title returns the title of the report by using its type.
+func title(r excel.Report) string {
+	if r.Type == "keyword" {
+		return "Keyword Report"
+	}
+	return "URL Report"
+}
+
+// colorOf returns the color, or the default one if it is not a hex code like "#000000".
+func colorOf(color, defaultColor string) string {
+	if !hexColor.MatchString(color) {
+		return defaultColor
+	}
+	return color
+}
+
+// percentage returns the percentage of the count, like "12.50%".
+func percentage(t excel.SummaryTable, c excel.Count) string {
+	return fmt.Sprintf("%.2f%%", t.Percentage(c)*100)
+}
+
+// isLink tells whether the text is a HTTP URL.
+func isLink(text string) bool {
+	return strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://")
+}
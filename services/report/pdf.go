@@ -0,0 +1,284 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/zeoagency/carbon/services/excel"
+)
+
+// Sizes of the PDF report, in millimeters.
+const (
+	pdfMargin       = 10.0
+	pdfHeaderHeight = 18.0
+	pdfLineHeight   = 4.5
+	pdfFontSize     = 8.0
+	pdfBarWidth     = 60.0
+	pdfDefaultWidth = 10.0 // used for columns that don't have a width in the layout.
+	pdfMaxLines     = 12   // long cells are cut, so a row is never longer than a page.
+)
+
+// pdfFont is the family of the fonts that are embedded to the report, they support UTF-8.
+const pdfFont = "Go"
+
+// pdfReport keeps the state while the PDF report is written.
+type pdfReport struct {
+	pdf       *gofpdf.Fpdf
+	report    excel.Report
+	color     [3]int
+	textColor [3]int
+	logo      string // the name of the registered image, it is empty if there is no logo.
+}
+
+// ConvertToPDF creates a PDF report by using the same data with the HTML report.
+// Pages are A4 landscape, tables are continued on the next pages with their titles.
+func ConvertToPDF(r excel.Report) (*bytes.Buffer, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin+pdfHeaderHeight+4, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.SetTitle(title(r), true)
+
+	p := &pdfReport{
+		pdf:       pdf,
+		report:    r,
+		color:     rgbOf(colorOf(r.Layout.Brand.Color, "#000000")),
+		textColor: rgbOf(colorOf(r.Layout.Styles.Title.Color, "#ffffff")),
+	}
+
+	logo, mimeType, err := r.Layout.Brand.ReadLogo()
+	if err != nil {
+		return nil, err
+	}
+	if logo != nil {
+		imageType := "PNG"
+		if mimeType == "image/jpeg" {
+			imageType = "JPG"
+		}
+		pdf.RegisterImageOptionsReader("logo", gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(logo))
+		p.logo = "logo"
+	}
+
+	pdf.SetHeaderFunc(p.header)
+	pdf.SetFooterFunc(p.footer)
+	pdf.AliasNbPages("")
+
+	pdf.AddPage()
+	if r.Summary != nil {
+		p.summary(*r.Summary)
+	}
+	for _, t := range r.Tables {
+		p.table(t)
+	}
+
+	b := &bytes.Buffer{}
+	err = pdf.Output(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// header draws the brand bar to the top of the page.
+func (p *pdfReport) header() {
+	width, _ := p.pdf.GetPageSize()
+	p.pdf.SetFillColor(p.color[0], p.color[1], p.color[2])
+	p.pdf.Rect(0, 0, width, pdfHeaderHeight, "F")
+
+	x := pdfMargin
+	if p.logo != "" {
+		p.pdf.ImageOptions(p.logo, x, 3, 0, pdfHeaderHeight-6, false, gofpdf.ImageOptions{}, 0, "")
+		x += p.pdf.GetImageInfo(p.logo).Width()*(pdfHeaderHeight-6)/p.pdf.GetImageInfo(p.logo).Height() + 4
+	}
+
+	text := title(p.report)
+	if p.report.Layout.Brand.Name != "" {
+		text = p.report.Layout.Brand.Name + " · " + text
+	}
+	p.pdf.SetFont(pdfFont, "B", 14)
+	p.pdf.SetTextColor(p.textColor[0], p.textColor[1], p.textColor[2])
+	p.pdf.SetXY(x, 0)
+	p.pdf.CellFormat(0, pdfHeaderHeight, text, "", 0, "LM", false, 0, "")
+
+	p.pdf.SetXY(pdfMargin, pdfMargin+pdfHeaderHeight+4)
+}
+
+// footer writes the page number to the bottom of the page.
+func (p *pdfReport) footer() {
+	p.pdf.SetY(-pdfMargin)
+	p.pdf.SetFont(pdfFont, "", pdfFontSize)
+	p.pdf.SetTextColor(128, 128, 128)
+	p.pdf.CellFormat(0, pdfLineHeight, fmt.Sprintf("%d / {nb}", p.pdf.PageNo()), "", 0, "R", false, 0, "")
+}
+
+// summary writes the request metadata and the summary tables with bars.
+func (p *pdfReport) summary(s excel.Summary) {
+	p.pdf.SetTextColor(0, 0, 0)
+	for _, field := range excel.MetaFields(s.Meta) {
+		p.pdf.SetFont(pdfFont, "B", pdfFontSize)
+		p.pdf.CellFormat(30, pdfLineHeight, field[0], "", 0, "L", false, 0, "")
+		p.pdf.SetFont(pdfFont, "", pdfFontSize)
+		p.pdf.CellFormat(0, pdfLineHeight, safeText(field[1]), "", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(pdfLineHeight)
+
+	widths := []float64{80, 25, 25, pdfBarWidth}
+	for _, t := range s.Tables {
+		p.ensureSpace(pdfLineHeight * 3)
+		p.titleRow(widths, []string{t.Title, "Count", "Percentage", ""})
+
+		p.pdf.SetFont(pdfFont, "", pdfFontSize)
+		for _, c := range t.Counts {
+			p.ensureSpace(pdfLineHeight)
+			p.pdf.SetTextColor(0, 0, 0)
+			p.pdf.CellFormat(widths[0], pdfLineHeight, safeText(c.Name), "B", 0, "L", false, 0, "")
+			p.pdf.CellFormat(widths[1], pdfLineHeight, strconv.Itoa(c.Count), "B", 0, "L", false, 0, "")
+			p.pdf.CellFormat(widths[2], pdfLineHeight, percentage(t, c), "B", 0, "L", false, 0, "")
+
+			x, y := p.pdf.GetXY()
+			p.pdf.SetFillColor(231, 230, 230)
+			p.pdf.Rect(x+2, y+1, pdfBarWidth-4, pdfLineHeight-2, "F")
+			p.pdf.SetFillColor(p.color[0], p.color[1], p.color[2])
+			if w := (pdfBarWidth - 4) * t.Percentage(c); w > 0 {
+				p.pdf.Rect(x+2, y+1, w, pdfLineHeight-2, "F")
+			}
+			p.pdf.Ln(pdfLineHeight)
+		}
+		p.pdf.Ln(pdfLineHeight)
+	}
+}
+
+// table writes the table, long texts are wrapped and links are clickable.
+func (p *pdfReport) table(t excel.Table) {
+	widths := p.columnWidths(t.Columns)
+	titles := []string{}
+	for _, c := range t.Columns {
+		titles = append(titles, c.Title)
+	}
+
+	p.ensureSpace(pdfLineHeight * 4)
+	p.pdf.SetFont(pdfFont, "B", 11)
+	p.pdf.SetTextColor(0, 0, 0)
+	p.pdf.CellFormat(0, pdfLineHeight*2, safeText(t.Title), "", 1, "L", false, 0, "")
+	p.titleRow(widths, titles)
+
+	t.EachRow(func(row []excel.Value) error {
+		p.pdf.SetFont(pdfFont, "", pdfFontSize)
+		lines := make([][]string, len(row))
+		height := 1
+		for i, v := range row {
+			lines[i] = p.pdf.SplitText(safeText(v.Text), widths[i])
+			if len(lines[i]) > pdfMaxLines {
+				lines[i] = append(lines[i][:pdfMaxLines-1], "…")
+			}
+			if len(lines[i]) > height {
+				height = len(lines[i])
+			}
+		}
+
+		if p.ensureSpace(float64(height) * pdfLineHeight) {
+			p.titleRow(widths, titles)
+		}
+		x, y := p.pdf.GetXY()
+		for i, v := range row {
+			p.cell(x, y, widths[i], float64(height)*pdfLineHeight, v, lines[i])
+			x += widths[i]
+		}
+		p.pdf.SetXY(pdfMargin, y+float64(height)*pdfLineHeight)
+		return nil
+	})
+	p.pdf.Ln(pdfLineHeight)
+}
+
+// cell writes the lines of the value to the given area.
+func (p *pdfReport) cell(x, y, w, h float64, v excel.Value, lines []string) {
+	style := ""
+	if v.Highlight {
+		style = "B"
+	}
+	p.pdf.SetFont(pdfFont, style, pdfFontSize)
+	switch {
+	case isLink(v.Text):
+		p.pdf.SetTextColor(6, 69, 173)
+		p.pdf.LinkString(x, y, w, h, v.Text)
+	case v.Muted:
+		p.pdf.SetTextColor(170, 170, 170)
+	default:
+		p.pdf.SetTextColor(0, 0, 0)
+	}
+
+	for i, line := range lines {
+		p.pdf.SetXY(x, y+float64(i)*pdfLineHeight)
+		p.pdf.CellFormat(w, pdfLineHeight, line, "", 0, "L", false, 0, "")
+	}
+	p.pdf.SetDrawColor(231, 230, 230)
+	p.pdf.Line(x, y+h, x+w, y+h)
+}
+
+// titleRow writes the titles with the brand color.
+func (p *pdfReport) titleRow(widths []float64, titles []string) {
+	p.pdf.SetFont(pdfFont, "B", pdfFontSize)
+	p.pdf.SetFillColor(p.color[0], p.color[1], p.color[2])
+	p.pdf.SetTextColor(p.textColor[0], p.textColor[1], p.textColor[2])
+	for i, title := range titles {
+		p.pdf.CellFormat(widths[i], pdfLineHeight+1, safeText(title), "", 0, "L", true, 0, "")
+	}
+	p.pdf.Ln(pdfLineHeight + 1)
+}
+
+// ensureSpace adds a page if the height doesn't fit to the current page.
+// It returns true if a page is added.
+func (p *pdfReport) ensureSpace(h float64) bool {
+	_, height := p.pdf.GetPageSize()
+	if p.pdf.GetY()+h <= height-pdfMargin*2 {
+		return false
+	}
+	p.pdf.AddPage()
+	return true
+}
+
+// columnWidths scales widths of the columns in the layout to the page width.
+func (p *pdfReport) columnWidths(columns []excel.Column) []float64 {
+	width, _ := p.pdf.GetPageSize()
+	width -= pdfMargin * 2
+
+	total := 0.0
+	widths := make([]float64, len(columns))
+	for i, c := range columns {
+		widths[i] = c.Width
+		if widths[i] == 0 {
+			widths[i] = pdfDefaultWidth
+		}
+		total += widths[i]
+	}
+	for i := range widths {
+		widths[i] = widths[i] * width / total
+	}
+	return widths
+}
+
+// rgbOf converts the hex color to RGB, the color must be like "#000000".
+func rgbOf(color string) [3]int {
+	r, _ := strconv.ParseUint(color[1:3], 16, 8)
+	g, _ := strconv.ParseUint(color[3:5], 16, 8)
+	b, _ := strconv.ParseUint(color[5:7], 16, 8)
+	return [3]int{int(r), int(g), int(b)}
+}
+
+// safeText replaces characters that are out of the fonts' range, like emojis.
+func safeText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0xFFFF {
+			return '?'
+		}
+		return r
+	}, text)
+}
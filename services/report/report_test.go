@@ -0,0 +1,98 @@
+package report
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/excel"
+)
+
+func TestConvertToHTML(t *testing.T) {
+	f, err := ConvertToHTML(excel.URLReport(testURLSet(), brandLayout(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := f.String()
+
+	for _, s := range []string{
+		"<h1>ZEO · URL Report</h1>",
+		"background: #ff6600",
+		`<img src="data:image/png;base64,`,
+		`<a href="https://boratanrikulu.dev/a-new">https://boratanrikulu.dev/a-new</a>`,
+		"<td>50.00%</td>",
+		"&lt;script&gt;",
+	} {
+		if !strings.Contains(html, s) {
+			t.Fatal("Error: HTML report must contain:", s)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Fatal("Error: Values must be escaped.")
+	}
+}
+
+func TestConvertToPDF(t *testing.T) {
+	for _, r := range []excel.Report{
+		excel.URLReport(testURLSet(), brandLayout(t)),
+		excel.KeywordReport(testKeywordSet(), excel.DefaultLayout()),
+	} {
+		f, err := ConvertToPDF(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(f.Bytes(), []byte("%PDF-")) {
+			t.Fatal("Error: PDF report is not valid.")
+		}
+		if r.Type == "url" && !bytes.Contains(f.Bytes(), []byte("/URI (https://boratanrikulu.dev/a-new)")) {
+			t.Fatal("Error: URLs must be links in the PDF report.")
+		}
+	}
+}
+
+// brandLayout returns a layout with a brand that has a logo in LAYOUTS_PATH.
+func brandLayout(t *testing.T) *excel.Layout {
+	dir := t.TempDir()
+	logo, err := os.Create(filepath.Join(dir, "logo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logo.Close()
+	err = png.Encode(logo, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("LAYOUTS_PATH", dir)
+	t.Cleanup(func() { os.Unsetenv("LAYOUTS_PATH") })
+
+	l, err := excel.ParseLayout([]byte(`{"brand": {"name": "ZEO", "logo": "logo.png", "color": "#ff6600"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func testURLSet() *models.URLSet {
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://boratanrikulu.dev/a", "https://boratanrikulu.dev/<script>")
+	urlSet.AddSuccess("https://boratanrikulu.dev/a", []string{"https://boratanrikulu.dev/a-new"})
+	urlSet.AddFail("https://boratanrikulu.dev/<script>", "There is no result.")
+	return urlSet
+}
+
+func testKeywordSet() *models.KeywordSet {
+	keywordSet := models.NewKeywordSet()
+	keywordSet.Add("carbon", "bora tanrıkulu 🚀")
+	keywordSet.AddSuccess("carbon", []models.KeywordSuccessResult{
+		{Title: "Carbon", Desc: strings.Repeat("A long description. ", 50), URL: "https://zeo.org/carbon"},
+	})
+	keywordSet.AddSuccess("bora tanrıkulu 🚀", []models.KeywordSuccessResult{
+		{Title: "Bora Tanrıkulu", Desc: "Blog", URL: "https://boratanrikulu.dev/"},
+	})
+	return keywordSet
+}
@@ -1,56 +1,282 @@
 package services
 
 import (
+	"context"
+	"log"
 	"net/http"
 
+	"github.com/zeoagency/carbon/helpers"
 	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/sitemap"
 )
 
-// keywords is an interface that includes ToStringSlice method.
-// You can use models.URLset or models.KeywordSet for this interface.
-// It used in SERP and DFS.
-type keywords interface {
-	ToStringSlice() []string
+// Provider names that are shown in the exports.
+const (
+	ProviderSerpApi = "SerpApi"
+	ProviderDFS     = "DataForSEO"
+	ProviderSitemap = "Sitemap"
+)
+
+// ReasonTimedOut is the fail reason of the values that are not looked up before the deadline.
+const ReasonTimedOut = "Timed out."
+
+// ReasonUnavailable is the fail reason of the values that no provider could answer.
+const ReasonUnavailable = "We could not get any result from the SERP providers."
+
+// serpResult is a result of a provider for a value.
+type serpResult struct {
+	Title       string
+	URL         string
+	Description string
+}
+
+// provider is a SERP resource that looks up the values.
+// Values that are answered are in the results even if they don't have any result,
+// values that are failed are in the errors.
+type provider struct {
+	Name   string
+	Lookup func(ctx context.Context, values []string, country, language string, serpLimit int) (map[string][]serpResult, map[string]error)
+}
+
+// providers are asked in the order, each one is only asked for the values that are not resolved by the earlier ones.
+var providers = []provider{
+	{Name: ProviderSerpApi, Lookup: getResultFromSerpApi},
+	{Name: ProviderDFS, Lookup: getResultFromDFSApi},
+}
+
+// ProvidersError is returned when all providers are failed for all values.
+type ProvidersError struct {
+	Errors map[string]error // the key is the provider name, the value is one of its errors.
+}
+
+func (e *ProvidersError) Error() string {
+	return "We have some issues with the SERP providers at this moment. Please try later."
+}
+
+// isSameOrAdded tells whether the alternative is the original URL itself,
+// or it is already added to the alternatives. Redirecting a page to itself creates a loop.
+func isSameOrAdded(alternative, originalURL string, added []string) bool {
+	if helpers.SameURL(alternative, originalURL) {
+		return true
+	}
+	for _, a := range added {
+		if helpers.SameURL(alternative, a) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetResultByUsingURLs add the result to the given URLSet by talking with Serp API or DFS.
-func GetResultByUsingURLs(urls *models.URLSet, country, language string) (int, error) {
-	response, _, err := getResultFromSerpApi(urls, country, language, 10)
-	if err == nil {
-		parseSERPResponseToFieldsForURLs(response, urls)
-		if len(urls.URLs) == 0 {
-			return http.StatusOK, nil
+// URLs that are not resolved by a provider are asked to the next one, attempts are kept for each URL.
+// If the context is done before all URLs are looked up, the rest is marked as timed out.
+func GetResultByUsingURLs(ctx context.Context, urls *models.URLSet, country, language string) (int, error) {
+	// The search key can be same for different URLs, so they are looked up together.
+	keys := make(map[string][]string) // the key is the search key, the values are keys of urls.URLs.
+	for key, url := range urls.URLs {
+		keys[url.String()] = append(keys[url.String()], key)
+	}
+
+	failed := lookup(ctx, urls.ToStringSlice(), country, language, func(name, value string, results []serpResult, err error) bool {
+		resolved := true
+		for _, key := range keys[value] {
+			url := urls.URLs[key]
+			if err != nil {
+				urls.AddAttempt(url.FullURL, models.Attempt{Provider: name, Error: err.Error()})
+				resolved = false
+				continue
+			}
+
+			r := matchURLs(results, url.FullURL, url.BaseURL)
+			urls.AddAttempt(url.FullURL, models.Attempt{Provider: name, Found: len(r) != 0})
+			if len(r) == 0 {
+				resolved = false
+				continue
+			}
+			urls.AddSuccess(url.FullURL, r)
+			urls.SetProvider(url.FullURL, name)
+			urls.Meta.AddProvider(name)
+			delete(urls.URLs, key)
 		}
+		return resolved
+	})
+	if len(failed) == len(providers) && ctx.Err() == nil {
+		return http.StatusServiceUnavailable, &ProvidersError{Errors: failed}
 	}
 
-	// That means there is still unprocessed URLs exist.
+	for _, url := range urls.URLs {
+		switch reason := failReason(ctx, urls.Attempts[url.FullURL], "We could not find any related URLs."); reason {
+		case ReasonTimedOut:
+			markURLAsTimedOut(urls, url.FullURL)
+		default:
+			urls.AddFail(url.FullURL, reason)
+		}
+	}
+	return http.StatusOK, nil
+}
 
-	dfsresponse, status, err := getResultFromDFSApi(urls, country, language, 10)
-	if err != nil {
-		return status, err
+// GetResultByUsingSitemap add the result to the given URLSet by matching the URLs with the sitemap.
+// It doesn't talk with any SERP provider.
+func GetResultByUsingSitemap(ctx context.Context, urls *models.URLSet, index *sitemap.Index) (int, error) {
+	urls.Meta.AddProvider(ProviderSitemap)
+	for key, url := range urls.URLs {
+		if ctx.Err() != nil {
+			markURLAsTimedOut(urls, url.FullURL)
+			continue
+		}
+
+		r := index.Match(url.FullURL, 3)
+		urls.AddAttempt(url.FullURL, models.Attempt{Provider: ProviderSitemap, Found: len(r) != 0})
+		if len(r) != 0 {
+			urls.AddSuccess(url.FullURL, r)
+			urls.SetProvider(url.FullURL, ProviderSitemap)
+			delete(urls.URLs, key)
+		} else {
+			urls.AddFail(url.FullURL, "We could not find any related URLs in the sitemap.")
+		}
 	}
 
-	parseDFSResponseToFieldsForURLs(dfsresponse, urls)
 	return http.StatusOK, nil
 }
 
 // GetResultByUsingKeywords returns related 10 results for each Keywords by talking with SEPR API or DFS.
-func GetResultByUsingKeywords(keywords *models.KeywordSet, country, language string) (int, error) {
-	response, _, err := getResultFromSerpApi(keywords, country, language, 10)
-	if err == nil {
-		parseSERPResponseToFieldsForKeywords(response, keywords)
-		if len(keywords.Keywords) == 0 {
-			return http.StatusOK, nil
+// Keywords that are not resolved by a provider are asked to the next one, attempts are kept for each keyword.
+// If the context is done before all keywords are looked up, the rest is marked as timed out.
+func GetResultByUsingKeywords(ctx context.Context, keywords *models.KeywordSet, country, language string) (int, error) {
+	failed := lookup(ctx, keywords.ToStringSlice(), country, language, func(name, keyword string, results []serpResult, err error) bool {
+		if err != nil {
+			keywords.AddAttempt(keyword, models.Attempt{Provider: name, Error: err.Error()})
+			return false
 		}
+
+		r := keywordResults(results)
+		keywords.AddAttempt(keyword, models.Attempt{Provider: name, Found: len(r) != 0})
+		if len(r) == 0 {
+			return false
+		}
+		keywords.AddSuccess(keyword, r)
+		keywords.SetProvider(keyword, name)
+		keywords.Meta.AddProvider(name)
+		delete(keywords.Keywords, keyword)
+		return true
+	})
+	if len(failed) == len(providers) && ctx.Err() == nil {
+		return http.StatusServiceUnavailable, &ProvidersError{Errors: failed}
 	}
 
-	// That means there is still unprocessed Keywords exist.
+	for keyword := range keywords.Keywords {
+		switch reason := failReason(ctx, keywords.Attempts[keyword], "We could not find any result."); reason {
+		case ReasonTimedOut:
+			markKeywordAsTimedOut(keywords, keyword)
+		default:
+			keywords.AddFail(keyword, reason)
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// lookup asks the providers for the values in the order, each provider is only asked for the values
+// that are not resolved yet. The resolve function is called for each value with the results or the error
+// of the provider, it tells whether the value is resolved.
+// It returns an error of each provider that failed for all values it was asked, the key is the provider name.
+func lookup(ctx context.Context, values []string, country, language string, resolve func(name, value string, results []serpResult, err error) bool) map[string]error {
+	failed := make(map[string]error)
+	for _, p := range providers {
+		if len(values) == 0 || ctx.Err() != nil {
+			break
+		}
 
-	dfsresponse, status, err := getResultFromDFSApi(keywords, country, language, 10)
-	if err != nil {
-		return status, err
+		results, errs := p.Lookup(ctx, values, country, language, 10)
+		unresolved := []string{}
+		for _, value := range values {
+			if !resolve(p.Name, value, results[value], errs[value]) {
+				unresolved = append(unresolved, value)
+			}
+		}
+
+		if len(errs) == len(values) {
+			for _, err := range errs {
+				failed[p.Name] = err
+				log.Printf("Error: %s is failed for all values. %v\n", p.Name, err)
+				break
+			}
+		}
+		values = unresolved
 	}
+	return failed
+}
 
-	parseDFSResponseToFieldsForKeywords(dfsresponse, keywords)
-	return http.StatusOK, nil
+// failReason returns the fail reason of a value that is not resolved by using its attempts.
+// It is timed out if the deadline is reached before every provider answered it.
+func failReason(ctx context.Context, attempts []models.Attempt, notFound string) string {
+	answered := 0
+	for _, attempt := range attempts {
+		if attempt.Error == "" {
+			answered++
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil && answered < len(providers):
+		return ReasonTimedOut
+	case answered == 0:
+		return ReasonUnavailable
+	}
+	return notFound
+}
+
+// matchURLs returns up to 3 results that are in the domain of the URL, the URL itself is excluded.
+func matchURLs(results []serpResult, fullURL, baseURL string) []string {
+	r := []string{}
+	for _, result := range results {
+		// Stop adding if there is already 3 URLs.
+		if len(r) == 3 {
+			break
+		}
+
+		urlDomain, _, err := helpers.ExtractURL(result.URL)
+		if err != nil {
+			continue // The result's URL is not a valid URL.
+		}
+		if urlDomain == baseURL && !isSameOrAdded(result.URL, fullURL, r) {
+			r = append(r, result.URL)
+		}
+	}
+	return r
+}
+
+// keywordResults returns up to 10 results that have URLs.
+func keywordResults(results []serpResult) []models.KeywordSuccessResult {
+	r := []models.KeywordSuccessResult{}
+	for _, result := range results {
+		// Stop adding if there is already 10 results.
+		if len(r) == 10 {
+			break
+		}
+
+		if result.URL != "" {
+			r = append(r, models.KeywordSuccessResult{
+				Title: result.Title,
+				Desc:  result.Description,
+				URL:   result.URL,
+			})
+		}
+	}
+	return r
+}
+
+// markURLAsTimedOut moves the URL to the fail list as timed out, it replaces the reason of an earlier provider.
+// The result is marked as partial.
+func markURLAsTimedOut(urls *models.URLSet, originalURL string) {
+	delete(urls.Fails, originalURL)
+	urls.AddFail(originalURL, ReasonTimedOut)
+	urls.Meta.TimedOut = true
+}
+
+// markKeywordAsTimedOut moves the keyword to the fail list as timed out, it replaces the reason of an earlier provider.
+// The result is marked as partial.
+func markKeywordAsTimedOut(keywords *models.KeywordSet, keyword string) {
+	delete(keywords.Fails, keyword)
+	keywords.AddFail(keyword, ReasonTimedOut)
+	keywords.Meta.TimedOut = true
 }
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/joho/godotenv"
@@ -26,7 +29,7 @@ func TestGetResultByUsingURLs(t *testing.T) {
 		"https://googlebunubulamaz.com.tr",
 	)
 
-	status, err := GetResultByUsingURLs(urlSet, "tr", "tr")
+	status, err := GetResultByUsingURLs(context.Background(), urlSet, "tr", "tr")
 	if err != nil {
 		t.Fatalf("STATUS: %d ERROR: %s", status, err)
 	}
@@ -53,7 +56,7 @@ func TestGetResultByUsingKeywords(t *testing.T) {
 		"googlebunubulamazcomtr",
 	)
 
-	status, err := GetResultByUsingKeywords(keywordSet, "tr", "tr")
+	status, err := GetResultByUsingKeywords(context.Background(), keywordSet, "tr", "tr")
 	if err != nil {
 		t.Fatalf("STATUS: %d ERROR: %s", status, err)
 	}
@@ -69,3 +72,100 @@ func TestGetResultByUsingKeywords(t *testing.T) {
 		fmt.Printf("\t\tREASON: %s\n", fail.Reason)
 	}
 }
+
+func TestGetResultShouldMarkTimedOutValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://zeo.org/broken", "https://zeo.org/missing")
+	status, err := GetResultByUsingURLs(ctx, urlSet, "tr", "tr")
+	if err != nil {
+		t.Fatalf("STATUS: %d ERROR: %s", status, err)
+	}
+	if !urlSet.Meta.TimedOut || len(urlSet.Fails) != 2 || urlSet.Fails["https://zeo.org/broken"].Reason != ReasonTimedOut {
+		t.Fatal("Error: URLs are not timed out.", urlSet.Fails)
+	}
+
+	keywordSet := models.NewKeywordSet()
+	keywordSet.Add("zeo", "carbon")
+	status, err = GetResultByUsingKeywords(ctx, keywordSet, "tr", "tr")
+	if err != nil {
+		t.Fatalf("STATUS: %d ERROR: %s", status, err)
+	}
+	if !keywordSet.Meta.TimedOut || len(keywordSet.Fails) != 2 || keywordSet.Fails["zeo"].Reason != ReasonTimedOut {
+		t.Fatal("Error: Keywords are not timed out.", keywordSet.Fails)
+	}
+}
+
+// fakeProviders replaces the providers, it returns the values that each provider is asked.
+// A provider answers the values that contain its answer, and fails for the others.
+func fakeProviders(answers map[string]string) (map[string][]string, func()) {
+	asked := make(map[string][]string)
+	original := providers
+	providers = []provider{}
+	for _, name := range []string{ProviderSerpApi, ProviderDFS} {
+		name := name
+		providers = append(providers, provider{Name: name, Lookup: func(ctx context.Context, values []string, country, language string, serpLimit int) (map[string][]serpResult, map[string]error) {
+			asked[name] = append(asked[name], values...)
+			results, errs := make(map[string][]serpResult), make(map[string]error)
+			for _, value := range values {
+				if answers[name] == "" || !strings.Contains(value, answers[name]) {
+					errs[value] = errors.New("Unavailable.")
+					continue
+				}
+				results[value] = []serpResult{{Title: value, URL: "https://zeo.org/" + answers[name] + "-new"}}
+			}
+			return results, errs
+		}})
+	}
+	return asked, func() { providers = original }
+}
+
+func TestGetResultByUsingURLsShouldFallBackPerURL(t *testing.T) {
+	asked, reset := fakeProviders(map[string]string{ProviderSerpApi: "first", ProviderDFS: "second"})
+	defer reset()
+
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://zeo.org/first", "https://zeo.org/second", "https://zeo.org/third")
+	status, err := GetResultByUsingURLs(context.Background(), urlSet, "tr", "tr")
+	if err != nil {
+		t.Fatalf("STATUS: %d ERROR: %s", status, err)
+	}
+
+	if len(asked[ProviderSerpApi]) != 3 || len(asked[ProviderDFS]) != 2 {
+		t.Fatal("Error: Resolved URLs are asked again.", asked)
+	}
+	if urlSet.Successes["https://zeo.org/first"].Provider != ProviderSerpApi || urlSet.Successes["https://zeo.org/second"].Provider != ProviderDFS {
+		t.Fatal("Error: Providers of the results are not kept.", urlSet.Successes)
+	}
+	if urlSet.Fails["https://zeo.org/third"].Reason != ReasonUnavailable || len(urlSet.Attempts["https://zeo.org/third"]) != 2 {
+		t.Fatal("Error: Attempts of the failed URL issue.", urlSet.Fails, urlSet.Attempts)
+	}
+	if len(urlSet.Meta.Providers) != 2 {
+		t.Fatal("Error: Providers are not in the meta.", urlSet.Meta.Providers)
+	}
+}
+
+func TestGetResultByUsingKeywordsShouldFailWhenAllProvidersFail(t *testing.T) {
+	_, reset := fakeProviders(map[string]string{ProviderDFS: "carbon"})
+	defer reset()
+
+	keywordSet := models.NewKeywordSet()
+	keywordSet.Add("zeo carbon", "zeo")
+	status, err := GetResultByUsingKeywords(context.Background(), keywordSet, "tr", "tr")
+	if err != nil || keywordSet.Successes["zeo carbon"].Provider != ProviderDFS {
+		t.Fatal("Error: Keywords must be found if a provider answers.", status, err)
+	}
+
+	_, reset = fakeProviders(map[string]string{})
+	defer reset()
+
+	keywordSet = models.NewKeywordSet()
+	keywordSet.Add("zeo carbon", "zeo")
+	status, err = GetResultByUsingKeywords(context.Background(), keywordSet, "tr", "tr")
+	var providersErr *ProvidersError
+	if status != 503 || !errors.As(err, &providersErr) || len(providersErr.Errors) != 2 {
+		t.Fatal("Error: All providers are failed, but there is no error.", status, err)
+	}
+}
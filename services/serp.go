@@ -2,16 +2,18 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/zeoagency/carbon/helpers"
-	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/httpclient"
+	"github.com/zeoagency/carbon/services/keypool"
 )
 
 // The API response includes this struct as an array for each keywords.
@@ -27,6 +29,20 @@ type serpApiResponse struct {
 	} `json:"result"`
 }
 
+// serpApiTries is the maximum count of keys that are tried for a request.
+const serpApiTries = 6
+
+// quotaStatuses are the statuses that SERP API responds when the quota of the key is exhausted or the key is not valid.
+var quotaStatuses = map[int]bool{
+	http.StatusUnauthorized:    true,
+	http.StatusPaymentRequired: true,
+	http.StatusForbidden:       true,
+	http.StatusTooManyRequests: true,
+}
+
+// serpKeys returns the key pool of SERP API, it is replaced in the tests.
+var serpKeys = keypool.Default
+
 type serpApiRequest struct {
 	Keywords  []string `json:"keyword"`
 	Gl        string   `json:"gl"`
@@ -35,11 +51,40 @@ type serpApiRequest struct {
 	Device    string   `json:"device"`
 }
 
-// getResultFromSerpApi returns SERP API Response for the given data.
-func getResultFromSerpApi(kws keywords, country, language string, serpLimit int) (map[string][]serpApiResponse, int, error) {
+// getResultFromSerpApi looks up the values by using SERP API, all values are sent in a request.
+// Only organic results are returned. Values that are not in the response are failed.
+func getResultFromSerpApi(ctx context.Context, values []string, country, language string, serpLimit int) (map[string][]serpResult, map[string]error) {
+	results, errs := make(map[string][]serpResult), make(map[string]error)
+
+	response, err := requestSerpApi(ctx, values, country, language, serpLimit)
+	for _, value := range values {
+		if err != nil {
+			errs[value] = err
+			continue
+		}
+
+		items, ok := response[value]
+		if !ok {
+			errs[value] = errors.New("The value is not in the response.")
+			continue
+		}
+		results[value] = []serpResult{}
+		for _, item := range items {
+			for _, v := range item.Result.Left {
+				if v.Type == "organic" {
+					results[value] = append(results[value], serpResult{Title: v.Title, URL: v.URL, Description: v.Snippet})
+				}
+			}
+		}
+	}
+	return results, errs
+}
+
+// requestSerpApi returns SERP API Response for the given data.
+func requestSerpApi(ctx context.Context, values []string, country, language string, serpLimit int) (map[string][]serpApiResponse, error) {
 	// Create the request body.
 	rq := serpApiRequest{
-		Keywords:  kws.ToStringSlice(),
+		Keywords:  values,
 		Gl:        country,
 		Hl:        language,
 		SerpLimit: strconv.Itoa(serpLimit),
@@ -48,131 +93,110 @@ func getResultFromSerpApi(kws keywords, country, language string, serpLimit int)
 
 	rqJson, err := json.Marshal(rq)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, err
 	}
 
-	api, selected := helpers.RandomAPICred()
-	if selected == -1 {
-		return nil, http.StatusServiceUnavailable, errors.New("We have some issues with the SERP API at this moment. Please try later.")
+	pool, err := serpKeys()
+	if err != nil {
+		log.Printf("Error: %v\n", err)
+		return nil, errors.New("We have some issues with the SERP API at this moment. Please try later.")
 	}
 
-	for i, _ := range api.Keys {
-		if i >= 6 {
-			break
-		}
-		// Example; If the selected is 4, Then it works like that: 4,5,6,7,8,9
-		address, key := api.Keys[(selected+i)%len(api.Keys)].Address, api.Keys[(selected+i)%len(api.Keys)].Key
-
-		// Create the request.
-		req, err := http.NewRequest("POST", address, bytes.NewReader(rqJson))
+	// Keys are selected by their health, a failed key is not tried again for the same values.
+	tried := make(map[int]bool)
+	for len(tried) < serpApiTries && ctx.Err() == nil {
+		i, key, err := pool.Next(tried)
 		if err != nil {
-			continue
-		}
-
-		// Set basic auth info.
-		req.Header.Add("x-api-key", key)
-
-		// Send the request.
-		c := &http.Client{
-			Timeout: 30 * time.Second,
-		}
-		res, err := c.Do(req)
-		if err != nil {
-			continue
-		}
-		defer res.Body.Close()
-
-		// Check the result's status code.
-		if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
-			log.Printf("Error: Unavailable SERP API Service. Status: %d\n", res.StatusCode)
-			continue
-		}
-
-		// Read the result, unmarshal it to the struct.
-		body, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			continue
+			log.Printf("Error: %v\n", err)
+			break
 		}
-		rsMap := make(map[string]map[string][]serpApiResponse)
-		_ = json.Unmarshal(body, &rsMap) // TODO: handle this error.
-
-		// Check if there is any result?!.
-		// If there is no result, we will be on the roads one more time.
-		for _, v := range rsMap {
-			for _, k := range v {
-				if len(k) != 0 {
-					return rsMap["data"], http.StatusOK, nil
-				}
-			}
+		tried[i] = true
+
+		start := time.Now()
+		r, err := sendSerpApiRequest(ctx, key, rqJson)
+		var quota *quotaError
+		switch {
+		case err == nil:
+			pool.Success(i, time.Since(start))
+			return r, nil
+		case ctx.Err() != nil:
+			// The key is not failed, the deadline is reached.
+		case errors.As(err, &quota):
+			log.Printf("Error: Quota of the SERP API key is exhausted. %v\n", err)
+			pool.Exhausted(i, quota.Wait, err)
+		default:
+			log.Printf("Error: Unavailable SERP API Service. %v\n", err)
+			pool.Failure(i, time.Since(start), err)
 		}
-
 	}
 
 	log.Println("Error: Unavailable SERP API Service.")
-	return nil, http.StatusServiceUnavailable, errors.New("We have some issues with the SERP API at this moment. Please try later.")
+	return nil, errors.New("We have some issues with the SERP API at this moment. Please try later.")
 }
 
-// parseResponseToFieldsForURLs extract the response to the URLSet.
-// It only adds to success list when domains are matched.
-// If it couldn't find any related URLs, it adds to the fail list.
-func parseSERPResponseToFieldsForURLs(response map[string][]serpApiResponse, urlSet *models.URLSet) {
-	for _, url := range urlSet.URLs {
-		r := []string{}
-		for _, value := range response[url.String()] {
-			for _, v := range value.Result.Left {
-				// Stop adding if there is already 3 URLs.
-				if len(r) == 3 {
-					break
-				}
+// quotaError is returned when the quota of the key is exhausted.
+type quotaError struct {
+	Status int
+	Wait   time.Duration // Retry-After of the response, it is zero if the response doesn't have it.
+}
 
-				urlDomain, _, err := helpers.ExtractURL(v.URL)
-				if err != nil {
-					continue // The result's URL is not a valid URL.
-				}
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("SERP API responded with %d status.", e.Status)
+}
 
-				if v.Type == "organic" && url.BaseURL == urlDomain {
-					r = append(r, v.URL)
-				}
-			}
-		}
-		// Add results to the lists.
-		if len(r) != 0 {
-			urlSet.AddSuccess(url.FullURL, r)
-			delete(urlSet.URLs, url.String())
-		} else {
-			urlSet.AddFail(url.FullURL, "We could not find any related URLs.")
+// sendSerpApiRequest sends the request body by using the key.
+func sendSerpApiRequest(ctx context.Context, key keypool.Key, rqJson []byte) (map[string][]serpApiResponse, error) {
+	// Create the request, quota statuses are not retried by the client so the next key is tried at once.
+	noRetry := []int{}
+	for status := range quotaStatuses {
+		noRetry = append(noRetry, status)
+	}
+	req, err := http.NewRequestWithContext(httpclient.WithoutRetries(ctx, noRetry...), "POST", key.Address, bytes.NewReader(rqJson))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set basic auth info.
+	req.Header.Add("x-api-key", key.Key)
+
+	// Send the request, other transient errors are retried by the client.
+	res, err := httpclient.Default().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// Check the result's status code.
+	switch {
+	case quotaStatuses[res.StatusCode]:
+		wait := time.Duration(0)
+		if after, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && after > 0 {
+			wait = time.Duration(after) * time.Second
 		}
+		return nil, &quotaError{Status: res.StatusCode, Wait: wait}
+	case !(res.StatusCode >= 200 && res.StatusCode <= 299):
+		return nil, fmt.Errorf("SERP API responded with %d status.", res.StatusCode)
 	}
-}
 
-// parseResponseToFieldsForKeywords extract the response to the KeywordSet.
-// It only adds to success list when the value is valid.
-// If it couldn't find any results, it adds to the fail list.
-func parseSERPResponseToFieldsForKeywords(response map[string][]serpApiResponse, keywordSet *models.KeywordSet) {
-	for keyword, _ := range keywordSet.Keywords {
-		r := []models.KeywordSuccessResult{}
-		for _, value := range response[keyword] {
-			for _, v := range value.Result.Left {
-				// Stop adding if there is already 10 results.
-				if len(r) == 10 {
-					break
-				}
+	// Read the result, unmarshal it to the struct.
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	rsMap := make(map[string]map[string][]serpApiResponse)
+	err = json.Unmarshal(body, &rsMap)
+	if err != nil {
+		return nil, fmt.Errorf("Unexpected SERP API response. %w", err)
+	}
 
-				if v.Type == "organic" && v.URL != "" {
-					r = append(r, models.KeywordSuccessResult{
-						Title: v.Title,
-						Desc:  v.Snippet,
-						URL:   v.URL,
-					})
-				}
+	// Check if there is any result?!.
+	// If there is no result, the next key is tried.
+	for _, v := range rsMap {
+		for _, k := range v {
+			if len(k) != 0 {
+				return rsMap["data"], nil
 			}
 		}
-		// Add results to the lists.
-		if len(r) != 0 {
-			keywordSet.AddSuccess(keyword, r)
-			delete(keywordSet.Keywords, keyword)
-		} else {
-			keywordSet.AddFail(keyword, "We could not find any result.")
-		}
 	}
+	return nil, errors.New("SERP API responded without any result.")
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zeoagency/carbon/services/keypool"
+)
+
+func TestMatchURLsShouldExcludeSelfMatches(t *testing.T) {
+	r := matchURLs([]serpResult{
+		{URL: "http://boratanrikulu.dev/archlinux-install/?utm_source=google"},
+		{URL: "https://boratanrikulu.dev/archlinux-kurulumu"},
+		{URL: "https://boratanrikulu.dev/archlinux-kurulumu/"},
+		{URL: "https://zeo.org/archlinux-kurulumu"},
+	}, "https://boratanrikulu.dev/archlinux-install", "boratanrikulu.dev")
+
+	if len(r) != 1 || r[0] != "https://boratanrikulu.dev/archlinux-kurulumu" {
+		t.Fatal("Error: Self match, duplicated alternative or another domain is not excluded.", r)
+	}
+}
+
+func TestRequestSerpApiShouldSkipExhaustedKeys(t *testing.T) {
+	hits := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.Header.Get("x-api-key")]++
+		if r.Header.Get("x-api-key") == "exhausted" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{"carbon":[{"result":{"left":[{"type":"organic","url":"https://zeo.org"}]}}]}}`))
+	}))
+	defer server.Close()
+
+	pool := keypool.New([]keypool.Key{
+		{Address: server.URL + "/exhausted", Key: "exhausted"},
+		{Address: server.URL + "/healthy", Key: "healthy"},
+	})
+	serpKeys = func() (*keypool.Pool, error) { return pool, nil }
+	defer func() { serpKeys = keypool.Default }()
+
+	for i := 0; i < 4; i++ {
+		r, err := requestSerpApi(context.Background(), []string{"carbon"}, "tr", "tr", 10)
+		if err != nil || len(r["carbon"]) != 1 {
+			t.Fatal("Error: Result is not returned by the healthy key.", r, err)
+		}
+	}
+	if hits["exhausted"] != 1 || pool.Status()[0].State != keypool.StateExhausted {
+		t.Fatal("Error: Exhausted key must be tried once, without the retries of the client.", hits, pool.Status())
+	}
+}
@@ -0,0 +1,217 @@
+package sheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/sheets/v4"
+)
+
+// scopes are requested for the client.
+// drive.file is not enough, existing spreadsheets and folders are not created by Carbon.
+var scopes = []string{drive.DriveScope, sheets.SpreadsheetsScope}
+
+var (
+	client     *http.Client
+	clientErr  error
+	clientOnce sync.Once
+)
+
+// getClient returns the client that is created by using the env file.
+// The client is created only once for the lambda instance, tokens are refreshed by the client.
+func getClient() (*http.Client, error) {
+	clientOnce.Do(func() {
+		client, clientErr = NewClientFromEnv(context.Background())
+	})
+	return client, clientErr
+}
+
+// NewClientFromEnv creates a client by using GOOGLE_APPLICATION_CREDENTIALS_JSON.
+//
+// The credentials can be;
+//
+//	a service account key: GOOGLE_IMPERSONATE_SUBJECT is used for domain-wide delegation if it is set.
+//	an OAuth client: the token is taken from the token store, refreshed tokens are saved to the store.
+func NewClientFromEnv(ctx context.Context) (*http.Client, error) {
+	credentials := []byte(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_JSON"))
+
+	var key struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(credentials, &key)
+	if err != nil {
+		return nil, wrap(ErrAuth, "Unable to parse credential secret key to config.", err)
+	}
+
+	if key.Type == "service_account" {
+		config, err := google.JWTConfigFromJSON(credentials, scopes...)
+		if err != nil {
+			return nil, wrap(ErrAuth, "Unable to parse service account key to config.", err)
+		}
+		config.Subject = os.Getenv("GOOGLE_IMPERSONATE_SUBJECT")
+		return config.Client(ctx), nil
+	}
+
+	config, err := google.ConfigFromJSON(credentials, scopes...)
+	if err != nil {
+		return nil, wrap(ErrAuth, "Unable to parse credential secret key to config.", err)
+	}
+
+	store, err := NewTokenStoreFromEnv()
+	if err != nil {
+		return nil, wrap(ErrAuth, "Unable to create the token store.", err)
+	}
+	tok, err := store.Load()
+	if err != nil {
+		return nil, wrap(ErrAuth, "Unable to load the token.", err)
+	}
+
+	src := oauth2.ReuseTokenSource(tok, &savingTokenSource{
+		src:   config.TokenSource(ctx, tok),
+		store: store,
+		last:  tok.AccessToken,
+	})
+	return oauth2.NewClient(ctx, src), nil
+}
+
+// TokenStore keeps the OAuth token, so refreshed tokens are not lost.
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(tok *oauth2.Token) error
+}
+
+// NewTokenStoreFromEnv creates the store that is defined by GOOGLE_TOKEN_STORE.
+//
+// Options:
+//
+//	"env" (default): reads GOOGLE_DRIVE_TOKEN_JSON, refreshed tokens are only kept in memory.
+//	"file": uses the JSON file at GOOGLE_TOKEN_STORE_PATH, GOOGLE_DRIVE_TOKEN_JSON is used if the file doesn't exist.
+//
+// "file" is for local runs and the CLI. Lambda instances don't share their files and lose them,
+// so use "env" there; the refresh token doesn't change, only access tokens are refreshed.
+func NewTokenStoreFromEnv() (TokenStore, error) {
+	switch os.Getenv("GOOGLE_TOKEN_STORE") {
+	case "", "env":
+		return &envTokenStore{}, nil
+	case "file":
+		if os.Getenv("GOOGLE_TOKEN_STORE_PATH") == "" {
+			return nil, fmt.Errorf("GOOGLE_TOKEN_STORE_PATH is not set.")
+		}
+		if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+			log.Printf("Warning: \"file\" token store is not shared by Lambda instances, use \"env\" instead.")
+		}
+		return &fileTokenStore{path: os.Getenv("GOOGLE_TOKEN_STORE_PATH")}, nil
+	default:
+		return nil, fmt.Errorf("Token store \"%s\" is not supported.", os.Getenv("GOOGLE_TOKEN_STORE"))
+	}
+}
+
+// envTokenStore reads the token from GOOGLE_DRIVE_TOKEN_JSON.
+type envTokenStore struct{}
+
+// Load returns the token in the env.
+func (s *envTokenStore) Load() (*oauth2.Token, error) {
+	return parseToken([]byte(os.Getenv("GOOGLE_DRIVE_TOKEN_JSON")))
+}
+
+// Save does nothing, the env is not changed.
+func (s *envTokenStore) Save(tok *oauth2.Token) error {
+	return nil
+}
+
+// fileTokenStore keeps the token in a JSON file.
+type fileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Load returns the token in the file, or the one in the env if the file doesn't exist.
+func (s *fileTokenStore) Load() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return (&envTokenStore{}).Load()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseToken(b)
+}
+
+// Save writes the token to the file, the file is replaced at once.
+func (s *fileTokenStore) Save(tok *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// parseToken parses the token, it must have an access or refresh token.
+func parseToken(b []byte) (*oauth2.Token, error) {
+	tok := &oauth2.Token{}
+	err := json.Unmarshal(b, tok)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the token: %w", err)
+	}
+	if tok.AccessToken == "" && tok.RefreshToken == "" {
+		return nil, fmt.Errorf("Token doesn't have an access or refresh token.")
+	}
+	return tok, nil
+}
+
+// savingTokenSource saves tokens to the store when they are refreshed.
+type savingTokenSource struct {
+	src   oauth2.TokenSource
+	store TokenStore
+	mu    sync.Mutex
+	last  string // the last access token that is saved.
+}
+
+// Token returns the token of the source, it is saved if it is a new one.
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tok.AccessToken != s.last {
+		// The refreshed token can be used even if it is not saved, the next refresh saves it again.
+		if err := s.store.Save(tok); err == nil {
+			s.last = tok.AccessToken
+		}
+	}
+	return tok, nil
+}
@@ -0,0 +1,101 @@
+package sheet
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+func TestFileTokenStoreShouldSaveRefreshedTokens(t *testing.T) {
+	refreshes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "new-access",
+			"refresh_token": "refresh",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	os.Setenv("GOOGLE_TOKEN_STORE", "file")
+	os.Setenv("GOOGLE_TOKEN_STORE_PATH", path)
+	defer os.Unsetenv("GOOGLE_TOKEN_STORE")
+	defer os.Unsetenv("GOOGLE_TOKEN_STORE_PATH")
+
+	store, err := NewTokenStoreFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The expired token is refreshed by the source, the new one must be saved.
+	expired := &oauth2.Token{AccessToken: "old-access", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	config := &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL}}
+	src := oauth2.ReuseTokenSource(expired, &savingTokenSource{
+		src:   config.TokenSource(context.Background(), expired),
+		store: store,
+		last:  expired.AccessToken,
+	})
+	for i := 0; i < 2; i++ {
+		tok, err := src.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.AccessToken != "new-access" {
+			t.Fatal("Error: Token is not refreshed.", tok.AccessToken)
+		}
+	}
+	if refreshes != 1 {
+		t.Fatal("Error: Valid tokens must be reused.", refreshes)
+	}
+
+	tok, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "new-access" || tok.RefreshToken != "refresh" {
+		t.Fatal("Error: Refreshed token is not saved.", tok)
+	}
+}
+
+func TestNewTokenStoreFromEnvShouldFail(t *testing.T) {
+	os.Setenv("GOOGLE_TOKEN_STORE", "redis")
+	defer os.Unsetenv("GOOGLE_TOKEN_STORE")
+	if _, err := NewTokenStoreFromEnv(); err == nil {
+		t.Fatal("Error: Unknown store must fail.")
+	}
+
+	os.Setenv("GOOGLE_TOKEN_STORE", "file")
+	if _, err := NewTokenStoreFromEnv(); err == nil {
+		t.Fatal("Error: File store must have a path.")
+	}
+}
+
+func TestNewClientFromEnvShouldUseServiceAccounts(t *testing.T) {
+	credentials, token := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_JSON"), os.Getenv("GOOGLE_DRIVE_TOKEN_JSON")
+	defer os.Setenv("GOOGLE_APPLICATION_CREDENTIALS_JSON", credentials)
+	defer os.Setenv("GOOGLE_DRIVE_TOKEN_JSON", token)
+
+	// Service accounts don't need a token.
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS_JSON", `{"type": "service_account", "client_email": "carbon@example.iam.gserviceaccount.com", "private_key": "key", "token_uri": "https://oauth2.googleapis.com/token"}`)
+	os.Setenv("GOOGLE_DRIVE_TOKEN_JSON", "")
+	if _, err := NewClientFromEnv(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("GOOGLE_APPLICATION_CREDENTIALS_JSON", "not-json")
+	if _, err := NewClientFromEnv(context.Background()); !errors.Is(err, ErrAuth) {
+		t.Fatal("Error: Invalid credentials must be an auth error.", err)
+	}
+}
@@ -0,0 +1,79 @@
+package sheet
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Kinds of the errors that are returned by Google calls, check them with errors.Is.
+var (
+	ErrAuth       = errors.New("Google credentials are not valid.")
+	ErrQuota      = errors.New("Google API quota is exceeded.")
+	ErrPermission = errors.New("Google account doesn't have the permission.")
+)
+
+// Error keeps the message of the failed operation with its cause and kind.
+type Error struct {
+	Kind    error // ErrAuth, ErrQuota, ErrPermission or nil if it is unknown.
+	Message string
+	Err     error
+}
+
+// Error returns the message with the cause.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return strings.TrimSuffix(e.Message, ".") + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is tells whether the error is the kind.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// wrap returns the error with the message and the kind.
+func wrap(kind error, message string, err error) error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// wrapAPI returns the error with the message, the kind is found by using the response of the API.
+func wrapAPI(message string, err error) error {
+	return wrap(kindOf(err), message, err)
+}
+
+// kindOf returns the kind of the error that is returned by the API or the token source.
+func kindOf(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return ErrAuth
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	switch apiErr.Code {
+	case http.StatusUnauthorized:
+		return ErrAuth
+	case http.StatusTooManyRequests:
+		return ErrQuota
+	case http.StatusForbidden:
+		for _, e := range apiErr.Errors {
+			if strings.Contains(e.Reason, "RateLimitExceeded") || strings.Contains(e.Reason, "rateLimitExceeded") || e.Reason == "quotaExceeded" {
+				return ErrQuota
+			}
+		}
+		return ErrPermission
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sheet
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestAPIWriterShouldSeparateErrors(t *testing.T) {
+	tests := []struct {
+		code   int
+		reason string
+		kind   error
+	}{
+		{http.StatusUnauthorized, "authError", ErrAuth},
+		{http.StatusTooManyRequests, "rateLimitExceeded", ErrQuota},
+		{http.StatusForbidden, "userRateLimitExceeded", ErrQuota},
+		{http.StatusForbidden, "insufficientPermissions", ErrPermission},
+		{http.StatusInternalServerError, "backendError", nil},
+	}
+
+	for _, test := range tests {
+		w := newFakeWriter(t, &fakeAPI{failCode: test.code, failReason: test.reason})
+		_, err := w.Write(context.Background(), testReport(), Options{})
+		if err == nil {
+			t.Fatal("Error: Write must fail.", test.reason)
+		}
+		for _, kind := range []error{ErrAuth, ErrQuota, ErrPermission} {
+			if errors.Is(err, kind) != (kind == test.kind) {
+				t.Fatal("Error: Kind of the error is not valid.", test.reason, err)
+			}
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Message != "Error occur while creating file on Google Sheets." {
+			t.Fatal("Error: Error must keep the message of the operation.", err)
+		}
+	}
+
+	// Quota errors of existing spreadsheets must not be reported as not found.
+	w := newFakeWriter(t, &fakeAPI{failCode: http.StatusForbidden, failReason: "rateLimitExceeded"})
+	_, err := w.Write(context.Background(), testReport(), Options{SpreadsheetID: "test-id"})
+	if err == ErrSpreadsheetNotFound || !errors.Is(err, ErrQuota) {
+		t.Fatal("Error: Quota error is not separated.", err)
+	}
+}
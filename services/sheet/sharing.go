@@ -0,0 +1,235 @@
+package sheet
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+)
+
+// Link accesses of the spreadsheet.
+const (
+	LinkAnyone  = "anyone"  // anyone who has the link.
+	LinkDomain  = "domain"  // users of the domain who have the link.
+	LinkPrivate = "private" // only the users that are listed.
+)
+
+// roles are the supported roles of the permissions.
+var roles = []string{"reader", "commenter", "writer"}
+
+// Sharing tells who can access the new spreadsheet.
+type Sharing struct {
+	Link     string // LinkAnyone (default), LinkDomain or LinkPrivate.
+	LinkRole string // role of the link, "reader" by default.
+	Domain   string // the domain for LinkDomain.
+	Users    []UserShare
+	Expires  int  // the permissions of the users expire after the days, if it is set.
+	Notify   bool // sends notification emails to the users.
+}
+
+// UserShare is a permission for an user.
+type UserShare struct {
+	Email string
+	Role  string // "reader" by default.
+}
+
+// ParseSharing parses the sharing spec, items are separated by commas.
+// It is used for both the request and the account.
+//
+// Items:
+//
+//	"anyone[:role]", "private" or "domain:<domain>[:role]"; the link access, "anyone" is the default.
+//	"<email>[:role]"; shares with the user, the role is "reader", "commenter" or "writer".
+//	"expires:<days>"; the permissions of the users expire after the days.
+//	"notify"; sends notification emails to the users.
+//
+// For example; "domain:zeo.org,bora@zeo.org:writer,client@example.com,expires:30".
+func ParseSharing(spec string) (Sharing, error) {
+	s := Sharing{Link: LinkAnyone, LinkRole: "reader"}
+	if strings.TrimSpace(spec) == "" {
+		return s, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		switch {
+		case parts[0] == "":
+			continue
+		case parts[0] == LinkAnyone && len(parts) <= 2:
+			s.Link = LinkAnyone
+			if len(parts) == 2 {
+				s.LinkRole = parts[1]
+			}
+		case parts[0] == LinkPrivate && len(parts) == 1:
+			s.Link = LinkPrivate
+		case parts[0] == LinkDomain && (len(parts) == 2 || len(parts) == 3) && parts[1] != "":
+			s.Link, s.Domain = LinkDomain, parts[1]
+			if len(parts) == 3 {
+				s.LinkRole = parts[2]
+			}
+		case parts[0] == "expires" && len(parts) == 2:
+			days, err := strconv.Atoi(parts[1])
+			if err != nil || days <= 0 {
+				return Sharing{}, fmt.Errorf("Expiration \"%s\" must be a count of days.", parts[1])
+			}
+			s.Expires = days
+		case parts[0] == "notify" && len(parts) == 1:
+			s.Notify = true
+		case strings.Contains(parts[0], "@") && len(parts) <= 2:
+			if _, err := mail.ParseAddress(parts[0]); err != nil {
+				return Sharing{}, fmt.Errorf("Email \"%s\" is not valid.", parts[0])
+			}
+			u := UserShare{Email: parts[0], Role: "reader"}
+			if len(parts) == 2 {
+				u.Role = parts[1]
+			}
+			if !isRole(u.Role) {
+				return Sharing{}, fmt.Errorf("Role \"%s\" must be \"reader\", \"commenter\" or \"writer\".", u.Role)
+			}
+			s.Users = append(s.Users, u)
+		default:
+			return Sharing{}, fmt.Errorf("Sharing item \"%s\" is not valid.", item)
+		}
+	}
+
+	if !isRole(s.LinkRole) {
+		return Sharing{}, fmt.Errorf("Role \"%s\" must be \"reader\", \"commenter\" or \"writer\".", s.LinkRole)
+	}
+
+	return s, nil
+}
+
+// linkLevels orders the link accesses from the narrowest one.
+var linkLevels = map[string]int{LinkPrivate: 0, LinkDomain: 1, LinkAnyone: 2}
+
+// Within returns an error if the sharing gives more access than the policy, the policy is a ceiling.
+// The link can't be wider, users must be in the policy or in its domain, roles can't be higher,
+// permissions can't expire later and notification emails can't be sent if the policy doesn't send them.
+func (s Sharing) Within(policy Sharing) error {
+	link, policyLink := linkOrAnyone(s.Link), linkOrAnyone(policy.Link)
+	if linkLevels[link] > linkLevels[policyLink] ||
+		(link == LinkDomain && policyLink == LinkDomain && !strings.EqualFold(s.Domain, policy.Domain)) {
+		return fmt.Errorf("Link access can't be wider than \"%s\".", policyLink)
+	}
+	if link != LinkPrivate && roleLevel(s.LinkRole) > roleLevel(policy.LinkRole) {
+		return fmt.Errorf("Link role can't be higher than \"%s\".", roleOrReader(policy.LinkRole))
+	}
+
+	for _, u := range s.Users {
+		role, ok := policy.roleOf(u.Email)
+		if !ok {
+			return fmt.Errorf("Spreadsheet can't be shared with \"%s\".", u.Email)
+		}
+		if roleLevel(u.Role) > roleLevel(role) {
+			return fmt.Errorf("Role of \"%s\" can't be higher than \"%s\".", u.Email, roleOrReader(role))
+		}
+	}
+
+	if len(s.Users) != 0 && policy.Expires != 0 && (s.Expires == 0 || s.Expires > policy.Expires) {
+		return fmt.Errorf("Permissions must expire in %d days.", policy.Expires)
+	}
+	if len(s.Users) != 0 && s.Notify && !policy.Notify {
+		return fmt.Errorf("Notification emails can't be sent.")
+	}
+	return nil
+}
+
+// roleOf returns the highest role that the sharing allows as a policy for the email.
+// Users of the policy have their roles, others in the domain of the link have the role of the link.
+func (s Sharing) roleOf(email string) (string, bool) {
+	for _, u := range s.Users {
+		if strings.EqualFold(u.Email, email) {
+			return u.Role, true
+		}
+	}
+	at := strings.LastIndex(email, "@")
+	if s.Link == LinkDomain && at != -1 && strings.EqualFold(email[at+1:], s.Domain) {
+		return s.LinkRole, true
+	}
+	return "", false
+}
+
+// share creates the permissions of the sharing for the file.
+func (w *APIWriter) share(ctx context.Context, fileID string, s Sharing) error {
+	switch s.Link {
+	case LinkAnyone, "":
+		_, err := w.Drive.Permissions.Create(fileID, &drive.Permission{
+			Type: "anyone",
+			Role: roleOrReader(s.LinkRole),
+		}).SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return wrapAPI("Error occur while creating file permission on Google Sheets.", err)
+		}
+	case LinkDomain:
+		_, err := w.Drive.Permissions.Create(fileID, &drive.Permission{
+			Type:   "domain",
+			Domain: s.Domain,
+			Role:   roleOrReader(s.LinkRole),
+		}).SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return wrapAPI(fmt.Sprintf("Error occur while sharing the file with \"%s\" domain on Google Sheets.", s.Domain), err)
+		}
+	}
+
+	for _, u := range s.Users {
+		p := &drive.Permission{
+			Type:         "user",
+			EmailAddress: u.Email,
+			Role:         roleOrReader(u.Role),
+		}
+		if s.Expires != 0 {
+			p.ExpirationTime = time.Now().AddDate(0, 0, s.Expires).UTC().Format(time.RFC3339)
+		}
+		_, err := w.Drive.Permissions.Create(fileID, p).
+			SendNotificationEmail(s.Notify).
+			SupportsAllDrives(true).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return wrapAPI(fmt.Sprintf("Error occur while sharing the file with \"%s\" on Google Sheets.", u.Email), err)
+		}
+	}
+
+	return nil
+}
+
+// roleOrReader returns the role, or "reader" if it is not set.
+func roleOrReader(role string) string {
+	if role == "" {
+		return "reader"
+	}
+	return role
+}
+
+// linkOrAnyone returns the link access, or LinkAnyone if it is not set.
+func linkOrAnyone(link string) string {
+	if link == "" {
+		return LinkAnyone
+	}
+	return link
+}
+
+// roleLevel returns the order of the role in roles, the empty role is "reader".
+func roleLevel(role string) int {
+	role = roleOrReader(role)
+	for i, r := range roles {
+		if r == role {
+			return i
+		}
+	}
+	return len(roles)
+}
+
+// isRole tells whether the role is supported.
+func isRole(role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
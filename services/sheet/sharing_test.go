@@ -0,0 +1,92 @@
+package sheet
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestParseSharing(t *testing.T) {
+	s, err := ParseSharing("")
+	if err != nil || s.Link != LinkAnyone || s.LinkRole != "reader" {
+		t.Fatal("Error: Anyone must read by default.", s, err)
+	}
+
+	s, err = ParseSharing("domain:zeo.org:commenter, bora@zeo.org:writer,client@example.com,expires:30,notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Link != LinkDomain || s.Domain != "zeo.org" || s.LinkRole != "commenter" || s.Expires != 30 || !s.Notify {
+		t.Fatal("Error: Sharing is not parsed.", s)
+	}
+	if len(s.Users) != 2 || s.Users[0] != (UserShare{"bora@zeo.org", "writer"}) || s.Users[1] != (UserShare{"client@example.com", "reader"}) {
+		t.Fatal("Error: Users are not parsed.", s.Users)
+	}
+
+	for _, spec := range []string{"everyone", "domain:", "bora@zeo.org:owner", "expires:never", "anyone:owner", "not an email@"} {
+		_, err = ParseSharing(spec)
+		if err == nil {
+			t.Fatal("Error: Sharing must not be valid.", spec)
+		}
+	}
+}
+
+func TestSharingShouldBeWithinThePolicy(t *testing.T) {
+	for _, test := range []struct {
+		policy, spec string
+		valid        bool
+	}{
+		{"", "", true},
+		{"", "private", true},
+		{"", "domain:zeo.org", true},
+		{"", "anyone:writer", false},
+		{"", "client@example.com", false},
+		{"", "notify", true},
+		{"domain:zeo.org:commenter", "anyone", false},
+		{"domain:zeo.org:commenter", "domain:example.com", false},
+		{"domain:zeo.org:commenter", "private,bora@zeo.org:commenter", true},
+		{"domain:zeo.org:commenter", "private,bora@zeo.org:writer", false},
+		{"private,client@example.com:writer,expires:30", "private,client@example.com:writer,expires:7", true},
+		{"private,client@example.com:writer,expires:30", "private,client@example.com", false},
+		{"private,client@example.com:writer,expires:30", "private,other@example.com,expires:7", false},
+		{"private,client@example.com", "private,client@example.com,notify", false},
+		{"private,client@example.com,notify", "private,client@example.com,notify", true},
+	} {
+		policy, err := ParseSharing(test.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := ParseSharing(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Within(policy); (err == nil) != test.valid {
+			t.Fatal("Error: Sharing must be narrower than the policy.", test.policy, test.spec, err)
+		}
+	}
+}
+
+func TestAPIWriterShouldShare(t *testing.T) {
+	api := &fakeAPI{}
+	w := newFakeWriter(t, api)
+
+	sharing, err := ParseSharing("private,bora@zeo.org:writer,expires:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(context.Background(), testReport(), Options{Sharing: sharing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.permissions) != 1 {
+		t.Fatal("Error: Private spreadsheets must only be shared with the users.", api.permissions)
+	}
+	p := api.permissions[0]
+	if p.Type != "user" || p.EmailAddress != "bora@zeo.org" || p.Role != "writer" || p.ExpirationTime == "" {
+		t.Fatal("Error: Permission of the user is not valid.", p)
+	}
+	if api.notify[0] != "false" {
+		t.Fatal("Error: Notification emails must not be sent by default.")
+	}
+}
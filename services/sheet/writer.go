@@ -0,0 +1,664 @@
+package sheet
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/zeoagency/carbon/services/excel"
+)
+
+// rowsPerRequest is the count of rows that are sent in a request, big requests are rejected by the API.
+const rowsPerRequest = 5000
+
+// numericFields are written as numbers, others are written as texts.
+var numericFields = map[string]bool{"duplicates": true, "hits": true}
+
+// Writer writes the report to a spreadsheet, and returns its URL.
+type Writer interface {
+	Write(ctx context.Context, r excel.Report, opts Options) (string, error)
+}
+
+// Ways to write to an existing spreadsheet.
+const (
+	AppendTabs = "tabs" // adds new tabs, titles are suffixed with the date if they are used.
+	AppendRows = "rows" // appends rows to the tabs that have the same titles, the summary is not written.
+)
+
+// ErrSpreadsheetNotFound is returned when the existing spreadsheet is not found or not accessible.
+var ErrSpreadsheetNotFound = errors.New("Spreadsheet is not found.")
+
+// ErrColumns is the kind of the error that is returned when rows can't be appended to an existing tab,
+// because a column of the result is not in the header row of the tab. Check it with errors.Is.
+var ErrColumns = errors.New("Columns of the existing tab don't match the result.")
+
+// Options tells where the report is written.
+type Options struct {
+	SpreadsheetID string // the existing spreadsheet, a new one is created if it is not set.
+	Append        string // AppendTabs (default) or AppendRows, for the existing spreadsheet.
+	Name          string // name of the new spreadsheet, FileName is used if it is not set.
+	FolderID      string // Drive folder or shared drive of the new spreadsheet.
+	Sharing       Sharing
+}
+
+// APIWriter writes reports by using Google Sheets API.
+// Tabs, header styles, column widths, filters, status colors and links are set directly,
+// so the spreadsheet looks like the excel file. Drive API is used to move and share it.
+type APIWriter struct {
+	Sheets *sheets.Service
+	Drive  *drive.Service
+}
+
+// NewWriter creates an APIWriter with the given options, like option.WithHTTPClient.
+// The drive endpoint is separated, so both services can be pointed to a fake server in the tests.
+func NewWriter(sheetsOpts, driveOpts []option.ClientOption) (*APIWriter, error) {
+	ctx := context.Background()
+	sheetsSrv, err := sheets.NewService(ctx, sheetsOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve Sheets client.")
+	}
+	driveSrv, err := drive.NewService(ctx, driveOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve Drive client.")
+	}
+	return &APIWriter{Sheets: sheetsSrv, Drive: driveSrv}, nil
+}
+
+// NewWriterFromEnv creates an APIWriter by using the client of the env file, see NewClientFromEnv.
+func NewWriterFromEnv() (*APIWriter, error) {
+	client, err := getClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(
+		[]option.ClientOption{option.WithHTTPClient(client)},
+		[]option.ClientOption{option.WithHTTPClient(client)},
+	)
+}
+
+// Write writes the report to a new spreadsheet or the existing one.
+// New spreadsheets are shared by using the sharing of the options, sharing of existing ones is not changed.
+func (w *APIWriter) Write(ctx context.Context, r excel.Report, opts Options) (string, error) {
+	if opts.SpreadsheetID != "" {
+		return w.writeTo(ctx, r, opts)
+	}
+
+	// Create the spreadsheet with its tabs.
+	name := opts.Name
+	if name == "" {
+		name = FileName(r)
+	}
+	tabs := []*sheets.Sheet{}
+	for _, properties := range tabsOf(r) {
+		properties.Index = int64(len(tabs))
+		tabs = append(tabs, &sheets.Sheet{Properties: properties})
+	}
+	s, err := w.Sheets.Spreadsheets.Create(&sheets.Spreadsheet{
+		Properties: &sheets.SpreadsheetProperties{Title: name},
+		Sheets:     tabs,
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", wrapAPI("Error occur while creating file on Google Sheets.", err)
+	}
+
+	ids := make(map[string]int64)
+	for _, sheet := range s.Sheets {
+		ids[sheet.Properties.Title] = sheet.Properties.SheetId
+	}
+	err = w.fill(ctx, s.SpreadsheetId, r, ids)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.FolderID != "" {
+		err = w.move(ctx, s.SpreadsheetId, opts.FolderID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = w.share(ctx, s.SpreadsheetId, opts.Sharing)
+	if err != nil {
+		return "", err
+	}
+
+	return spreadsheetURL(s.SpreadsheetId), nil
+}
+
+// writeTo writes the report to the existing spreadsheet as new tabs or rows.
+func (w *APIWriter) writeTo(ctx context.Context, r excel.Report, opts Options) (string, error) {
+	s, err := w.Sheets.Spreadsheets.Get(opts.SpreadsheetID).Fields("sheets.properties").Context(ctx).Do()
+	if e, ok := err.(*googleapi.Error); ok && kindOf(err) != ErrQuota && (e.Code == http.StatusNotFound || e.Code == http.StatusForbidden) {
+		return "", ErrSpreadsheetNotFound
+	}
+	if err != nil {
+		return "", wrapAPI("Error occur while reading the spreadsheet on Google Sheets.", err)
+	}
+
+	existing := make(map[string]int64)
+	for _, sheet := range s.Sheets {
+		existing[sheet.Properties.Title] = sheet.Properties.SheetId
+	}
+
+	// Tables are appended to the tabs that have the same titles, others are added as new tabs.
+	appends := []excel.Table{}
+	if opts.Append == AppendRows {
+		tables := []excel.Table{}
+		for _, t := range r.Tables {
+			if _, ok := existing[t.Title]; ok {
+				appends = append(appends, t)
+			} else {
+				tables = append(tables, t)
+			}
+		}
+		r.Summary, r.Tables = nil, tables
+	}
+
+	// Values are appended under the columns that have the same titles, so the header rows are read first.
+	headers, err := w.headersOf(ctx, opts.SpreadsheetID, appends)
+	if err != nil {
+		return "", err
+	}
+
+	// Add new tabs, titles are made unique by using the date of the report.
+	requests := []*sheets.Request{}
+	titles := []string{}
+	for _, properties := range tabsOf(r) {
+		titles = append(titles, properties.Title)
+		properties.Title = uniqueTitle(properties.Title, r.Meta.Date.UTC().Format("2006-01-02 15:04"), existing)
+		existing[properties.Title] = -1
+		requests = append(requests, &sheets.Request{AddSheet: &sheets.AddSheetRequest{Properties: properties}})
+	}
+	ids := make(map[string]int64)
+	if len(requests) != 0 {
+		res, err := w.Sheets.Spreadsheets.BatchUpdate(opts.SpreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+			Requests: requests,
+		}).Context(ctx).Do()
+		if err != nil {
+			return "", wrapAPI("Error occur while writing the result to Google Sheets.", err)
+		}
+		if len(res.Replies) != len(titles) {
+			return "", fmt.Errorf("Error occur while writing the result to Google Sheets.")
+		}
+		for i, reply := range res.Replies {
+			if reply.AddSheet == nil || reply.AddSheet.Properties == nil {
+				return "", fmt.Errorf("Error occur while writing the result to Google Sheets.")
+			}
+			ids[titles[i]] = reply.AddSheet.Properties.SheetId
+		}
+	}
+
+	err = w.fill(ctx, opts.SpreadsheetID, r, ids)
+	if err != nil {
+		return "", err
+	}
+
+	for _, t := range appends {
+		err = w.appendRows(ctx, opts.SpreadsheetID, existing[t.Title], r.Layout, t, headers[t.Title])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return spreadsheetURL(opts.SpreadsheetID), nil
+}
+
+// fill writes the summary and the tables of the report to the tabs.
+// The key of ids is the title of the table, the value is the ID of its tab.
+func (w *APIWriter) fill(ctx context.Context, spreadsheetID string, r excel.Report, ids map[string]int64) error {
+	// Set the summary and formats of the tables, and then the rows of the tables as chunks.
+	requests := []*sheets.Request{}
+	if r.Summary != nil {
+		requests = append(requests, summaryRequests(ids[r.Summary.Title], r.Layout, *r.Summary)...)
+	}
+	for _, t := range r.Tables {
+		requests = append(requests, formatRequests(ids[t.Title], r.Layout, t)...)
+	}
+	err := w.update(ctx, spreadsheetID, requests)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range r.Tables {
+		sheetID := ids[t.Title]
+		err = chunksOf(r.Layout, t, func(start int, rows []*sheets.RowData) error {
+			return w.update(ctx, spreadsheetID, []*sheets.Request{{UpdateCells: &sheets.UpdateCellsRequest{
+				Start:  &sheets.GridCoordinate{SheetId: sheetID, RowIndex: int64(start + 1)},
+				Rows:   rows,
+				Fields: "userEnteredValue,userEnteredFormat",
+			}}})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendRows appends rows of the table after the last row of the tab, as chunks.
+// Values are moved under the columns of the header, other columns of the tab are left empty.
+func (w *APIWriter) appendRows(ctx context.Context, spreadsheetID string, sheetID int64, layout *excel.Layout, t excel.Table, h header) error {
+	return chunksOf(layout, t, func(start int, rows []*sheets.RowData) error {
+		for _, row := range rows {
+			cells := make([]*sheets.CellData, h.width)
+			for i := range cells {
+				cells[i] = &sheets.CellData{}
+			}
+			for i, cell := range row.Values {
+				cells[h.positions[i]] = cell
+			}
+			row.Values = cells
+		}
+		return w.update(ctx, spreadsheetID, []*sheets.Request{{AppendCells: &sheets.AppendCellsRequest{
+			SheetId: sheetID,
+			Rows:    rows,
+			Fields:  "userEnteredValue,userEnteredFormat",
+		}}})
+	})
+}
+
+// header keeps the columns of an existing tab.
+type header struct {
+	width     int   // count of the columns in the header row.
+	positions []int // index of each column of the table in the header row.
+}
+
+// headersOf reads the header rows of the tabs that have the titles of the tables, and maps the columns by their titles.
+// An ErrColumns error is returned if a column of a table is not in its tab, so nothing is written.
+func (w *APIWriter) headersOf(ctx context.Context, spreadsheetID string, tables []excel.Table) (map[string]header, error) {
+	headers := make(map[string]header)
+	if len(tables) == 0 {
+		return headers, nil
+	}
+
+	ranges := []string{}
+	for _, t := range tables {
+		ranges = append(ranges, "'"+strings.ReplaceAll(t.Title, "'", "''")+"'!1:1")
+	}
+	res, err := w.Sheets.Spreadsheets.Values.BatchGet(spreadsheetID).Ranges(ranges...).MajorDimension("ROWS").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPI("Error occur while reading the spreadsheet on Google Sheets.", err)
+	}
+	if len(res.ValueRanges) != len(tables) {
+		return nil, fmt.Errorf("Error occur while reading the spreadsheet on Google Sheets.")
+	}
+
+	for i, t := range tables {
+		titles := map[string]int{}
+		h := header{}
+		if values := res.ValueRanges[i].Values; len(values) != 0 {
+			h.width = len(values[0])
+			for j := len(values[0]) - 1; j >= 0; j-- {
+				titles[strings.TrimSpace(fmt.Sprint(values[0][j]))] = j // the first one is used for the same titles.
+			}
+		}
+		for _, c := range t.Columns {
+			j, ok := titles[c.Title]
+			if !ok {
+				return nil, wrap(ErrColumns, fmt.Sprintf("Column \"%s\" is not in the \"%s\" tab.", c.Title, t.Title), nil)
+			}
+			h.positions = append(h.positions, j)
+		}
+		headers[t.Title] = h
+	}
+	return headers, nil
+}
+
+// move moves the spreadsheet to the folder, the folder can be in a shared drive.
+func (w *APIWriter) move(ctx context.Context, spreadsheetID, folderID string) error {
+	f, err := w.Drive.Files.Get(spreadsheetID).Fields("parents").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return wrapAPI("Error occur while moving the file on Google Drive.", err)
+	}
+	_, err = w.Drive.Files.Update(spreadsheetID, &drive.File{}).
+		AddParents(folderID).
+		RemoveParents(strings.Join(f.Parents, ",")).
+		SupportsAllDrives(true).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return wrapAPI("Error occur while moving the file to the folder on Google Drive.", err)
+	}
+	return nil
+}
+
+// update sends the requests as a batch.
+func (w *APIWriter) update(ctx context.Context, spreadsheetID string, requests []*sheets.Request) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	_, err := w.Sheets.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Context(ctx).Do()
+	if err != nil {
+		return wrapAPI("Error occur while writing the result to Google Sheets.", err)
+	}
+	return nil
+}
+
+// FileName returns the default name of the spreadsheet by using the type, the country, the language and the date,
+// like "Carbon url tr-tr 2020-12-01 15:04".
+func FileName(r excel.Report) string {
+	return fmt.Sprintf("Carbon %s %s-%s %s", r.Type, r.Meta.Country, r.Meta.Language, r.Meta.Date.UTC().Format("2006-01-02 15:04"))
+}
+
+// spreadsheetURL returns the URL of the spreadsheet.
+func spreadsheetURL(spreadsheetID string) string {
+	return "https://docs.google.com/spreadsheets/d/" + spreadsheetID
+}
+
+// uniqueTitle returns the title if it is not used, otherwise the title with the suffix.
+// A number is added too if the title with the suffix is used.
+func uniqueTitle(title, suffix string, used map[string]int64) string {
+	if _, ok := used[title]; !ok {
+		return title
+	}
+	r := title + " " + suffix
+	for i := 2; ; i++ {
+		if _, ok := used[r]; !ok {
+			return r
+		}
+		r = fmt.Sprintf("%s %s (%d)", title, suffix, i)
+	}
+}
+
+// tabsOf returns properties of the tabs of the report, grids are sized to fit the rows.
+func tabsOf(r excel.Report) []*sheets.SheetProperties {
+	tabs := []*sheets.SheetProperties{}
+	if r.Summary != nil {
+		tabs = append(tabs, &sheets.SheetProperties{Title: r.Summary.Title})
+	}
+	for _, t := range r.Tables {
+		grid := &sheets.GridProperties{
+			RowCount:    int64(t.Size + 1),
+			ColumnCount: int64(len(t.Columns)),
+		}
+		if t.Freeze {
+			grid.FrozenRowCount = 1
+		}
+		tabs = append(tabs, &sheets.SheetProperties{Title: t.Title, GridProperties: grid})
+	}
+	return tabs
+}
+
+// formatRequests returns requests to set the header row, column widths, the filter and status colors of the table.
+func formatRequests(sheetID int64, layout *excel.Layout, t excel.Table) []*sheets.Request {
+	header := []*sheets.CellData{}
+	for _, c := range t.Columns {
+		header = append(header, &sheets.CellData{
+			UserEnteredValue:  stringValue(c.Title),
+			UserEnteredFormat: cellFormat(layout.Styles.Title),
+		})
+	}
+	requests := []*sheets.Request{{UpdateCells: &sheets.UpdateCellsRequest{
+		Start:  &sheets.GridCoordinate{SheetId: sheetID},
+		Rows:   []*sheets.RowData{{Values: header}},
+		Fields: "userEnteredValue,userEnteredFormat",
+	}}}
+
+	for i, c := range t.Columns {
+		if c.Width != 0 {
+			requests = append(requests, columnWidthRequest(sheetID, i, c.Width))
+		}
+
+		if c.Field == "status" && t.Size != 0 {
+			for _, status := range statuses() {
+				colors := excel.StatusColors[status]
+				requests = append(requests, &sheets.Request{AddConditionalFormatRule: &sheets.AddConditionalFormatRuleRequest{
+					Rule: &sheets.ConditionalFormatRule{
+						Ranges: []*sheets.GridRange{{
+							SheetId:          sheetID,
+							StartRowIndex:    1,
+							EndRowIndex:      int64(t.Size + 1),
+							StartColumnIndex: int64(i),
+							EndColumnIndex:   int64(i + 1),
+						}},
+						BooleanRule: &sheets.BooleanRule{
+							Condition: &sheets.BooleanCondition{
+								Type:   "TEXT_EQ",
+								Values: []*sheets.ConditionValue{{UserEnteredValue: status}},
+							},
+							Format: cellFormat(excel.Style{Color: colors[0], Fill: colors[1]}),
+						},
+					},
+				}})
+			}
+		}
+	}
+
+	if t.Filter && len(t.Columns) != 0 {
+		requests = append(requests, &sheets.Request{SetBasicFilter: &sheets.SetBasicFilterRequest{
+			Filter: &sheets.BasicFilter{Range: &sheets.GridRange{
+				SheetId:          sheetID,
+				StartRowIndex:    0,
+				EndRowIndex:      int64(t.Size + 1),
+				StartColumnIndex: 0,
+				EndColumnIndex:   int64(len(t.Columns)),
+			}},
+		}})
+	}
+
+	return requests
+}
+
+// chunksOf calls f with each rowsPerRequest rows of the table and the index of the first one.
+// Only one chunk is kept in the memory at a time.
+func chunksOf(layout *excel.Layout, t excel.Table, f func(start int, rows []*sheets.RowData) error) error {
+	start := 0
+	rows := []*sheets.RowData{}
+	err := t.EachRow(func(values []excel.Value) error {
+		rows = append(rows, rowOf(layout, t, values))
+		if len(rows) < rowsPerRequest {
+			return nil
+		}
+		err := f(start, rows)
+		start, rows = start+len(rows), []*sheets.RowData{}
+		return err
+	})
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	return f(start, rows)
+}
+
+// rowOf returns the row of the values.
+// URLs are written as links, styles of the columns and the values are kept.
+func rowOf(layout *excel.Layout, t excel.Table, values []excel.Value) *sheets.RowData {
+	cells := []*sheets.CellData{}
+	for i, v := range values {
+		cell := &sheets.CellData{UserEnteredValue: extendedValue(t.Columns[i].Field, v.Text)}
+		switch {
+		case v.Highlight:
+			cell.UserEnteredFormat = cellFormat(layout.Styles.Highlight)
+		case v.Muted:
+			cell.UserEnteredFormat = cellFormat(layout.Styles.Muted)
+		case t.Columns[i].Style != nil && v.Text != "":
+			cell.UserEnteredFormat = cellFormat(*t.Columns[i].Style)
+		}
+		cells = append(cells, cell)
+	}
+	return &sheets.RowData{Values: cells}
+}
+
+// summaryRequests returns requests to write the summary like the summary sheet of the excel file.
+func summaryRequests(sheetID int64, layout *excel.Layout, s excel.Summary) []*sheets.Request {
+	rows := []*sheets.RowData{}
+	for _, field := range excel.MetaFields(s.Meta) {
+		rows = append(rows, &sheets.RowData{Values: []*sheets.CellData{
+			{UserEnteredValue: stringValue(field[0]), UserEnteredFormat: cellFormat(layout.Styles.Highlight)},
+			{UserEnteredValue: stringValue(field[1])},
+		}})
+	}
+	rows = append(rows, &sheets.RowData{})
+
+	starts := []int{}
+	for _, table := range s.Tables {
+		starts = append(starts, len(rows)+1)
+		header := []*sheets.CellData{}
+		for _, title := range []string{table.Title, "Count", "Percentage"} {
+			header = append(header, &sheets.CellData{
+				UserEnteredValue:  stringValue(title),
+				UserEnteredFormat: cellFormat(layout.Styles.Title),
+			})
+		}
+		rows = append(rows, &sheets.RowData{Values: header})
+
+		for _, c := range table.Counts {
+			count, percentage := float64(c.Count), table.Percentage(c)
+			rows = append(rows, &sheets.RowData{Values: []*sheets.CellData{
+				{UserEnteredValue: stringValue(c.Name)},
+				{UserEnteredValue: &sheets.ExtendedValue{NumberValue: &count}},
+				{
+					UserEnteredValue:  &sheets.ExtendedValue{NumberValue: &percentage},
+					UserEnteredFormat: &sheets.CellFormat{NumberFormat: &sheets.NumberFormat{Type: "PERCENT", Pattern: "0.00%"}},
+				},
+			}})
+		}
+		rows = append(rows, &sheets.RowData{})
+	}
+
+	requests := []*sheets.Request{
+		{UpdateCells: &sheets.UpdateCellsRequest{
+			Start:  &sheets.GridCoordinate{SheetId: sheetID},
+			Rows:   rows,
+			Fields: "userEnteredValue,userEnteredFormat",
+		}},
+		columnWidthRequest(sheetID, 0, 40),
+		columnWidthRequest(sheetID, 1, 15),
+		columnWidthRequest(sheetID, 2, 15),
+	}
+
+	if s.Chart != nil {
+		table := s.Tables[s.Chart.Table]
+		requests = append(requests, chartRequest(sheetID, *s.Chart, table, starts[s.Chart.Table]))
+	}
+
+	return requests
+}
+
+// chartRequest returns the request to add the chart of the table next to the tables.
+// The row is the index of the first row of the counts.
+func chartRequest(sheetID int64, chart excel.SummaryChart, table excel.SummaryTable, row int) *sheets.Request {
+	column := func(i int64) *sheets.ChartData {
+		return &sheets.ChartData{SourceRange: &sheets.ChartSourceRange{Sources: []*sheets.GridRange{{
+			SheetId:          sheetID,
+			StartRowIndex:    int64(row),
+			EndRowIndex:      int64(row + len(table.Counts)),
+			StartColumnIndex: i,
+			EndColumnIndex:   i + 1,
+		}}}}
+	}
+
+	spec := &sheets.ChartSpec{Title: table.Title}
+	if chart.Type == "pie" {
+		spec.PieChart = &sheets.PieChartSpec{Domain: column(0), Series: column(1), LegendPosition: "RIGHT_LEGEND"}
+	} else {
+		spec.BasicChart = &sheets.BasicChartSpec{
+			ChartType:      "BAR",
+			LegendPosition: "NO_LEGEND",
+			Domains:        []*sheets.BasicChartDomain{{Domain: column(0)}},
+			Series:         []*sheets.BasicChartSeries{{Series: column(1)}},
+		}
+	}
+
+	return &sheets.Request{AddChart: &sheets.AddChartRequest{Chart: &sheets.EmbeddedChart{
+		Spec: spec,
+		Position: &sheets.EmbeddedObjectPosition{OverlayPosition: &sheets.OverlayPosition{
+			AnchorCell: &sheets.GridCoordinate{SheetId: sheetID, RowIndex: 1, ColumnIndex: 4},
+		}},
+	}}}
+}
+
+// columnWidthRequest returns the request to set the width of the column.
+// The width is in characters like excel, it is converted to pixels.
+func columnWidthRequest(sheetID int64, column int, width float64) *sheets.Request {
+	return &sheets.Request{UpdateDimensionProperties: &sheets.UpdateDimensionPropertiesRequest{
+		Range: &sheets.DimensionRange{
+			SheetId:    sheetID,
+			Dimension:  "COLUMNS",
+			StartIndex: int64(column),
+			EndIndex:   int64(column + 1),
+		},
+		Properties: &sheets.DimensionProperties{PixelSize: int64(width * 7)},
+		Fields:     "pixelSize",
+	}}
+}
+
+// extendedValue returns the value of the cell; numeric fields are numbers and URLs are links.
+func extendedValue(field, text string) *sheets.ExtendedValue {
+	if text == "" {
+		return nil
+	}
+	if numericFields[field] {
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return &sheets.ExtendedValue{NumberValue: &n}
+		}
+	}
+	if strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://") {
+		formula := fmt.Sprintf(`=HYPERLINK("%s")`, strings.ReplaceAll(text, `"`, `""`))
+		return &sheets.ExtendedValue{FormulaValue: &formula}
+	}
+	return stringValue(text)
+}
+
+// statuses returns the statuses that have colors, in a stable order.
+func statuses() []string {
+	r := []string{}
+	for status := range excel.StatusColors {
+		r = append(r, status)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// stringValue returns the text as the value of a cell.
+func stringValue(text string) *sheets.ExtendedValue {
+	return &sheets.ExtendedValue{StringValue: &text}
+}
+
+// cellFormat converts the style of the layout to the cell format.
+func cellFormat(s excel.Style) *sheets.CellFormat {
+	f := &sheets.CellFormat{TextFormat: &sheets.TextFormat{
+		FontFamily: s.Font,
+		FontSize:   int64(s.Size),
+		Bold:       s.Bold,
+	}}
+	if s.Color != "" {
+		f.TextFormat.ForegroundColor = colorOf(s.Color)
+	}
+	if s.Fill != "" {
+		f.BackgroundColor = colorOf(s.Fill)
+	}
+	if s.Align != "" {
+		f.HorizontalAlignment = strings.ToUpper(s.Align)
+		f.VerticalAlignment = "MIDDLE"
+	}
+	return f
+}
+
+// colorOf converts the hex color like "#000000" to the color of the API.
+func colorOf(hex string) *sheets.Color {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return nil
+	}
+	c := [3]float64{}
+	for i := range c {
+		v, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return nil
+		}
+		c[i] = float64(v) / 255
+	}
+	return &sheets.Color{Red: c[0], Green: c[1], Blue: c[2]}
+}
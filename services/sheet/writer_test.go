@@ -0,0 +1,266 @@
+package sheet
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/excel"
+)
+
+// fakeAPI is a local stand-in for Sheets and Drive APIs, it keeps the requests.
+// "test-id" is the only spreadsheet, it has the tabs in existing.
+type fakeAPI struct {
+	existing    []string
+	headers     map[string][]interface{} // header rows of the existing tabs, the key is the title.
+	spreadsheet *sheets.Spreadsheet
+	requests    []*sheets.Request
+	permissions []*drive.Permission
+	notify      []string // sendNotificationEmail params of the permissions.
+	parents     string   // the query of the last file update, like "addParents=...&removeParents=...".
+	failCode    int      // all requests fail with the code and the reason, if it is set.
+	failReason  string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case f.failCode != 0:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.failCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{
+			"code":    f.failCode,
+			"message": f.failReason,
+			"errors":  []map[string]string{{"reason": f.failReason, "message": f.failReason}},
+		}})
+	case r.URL.Path == "/v4/spreadsheets":
+		f.spreadsheet = &sheets.Spreadsheet{}
+		json.NewDecoder(r.Body).Decode(f.spreadsheet)
+		f.spreadsheet.SpreadsheetId = "test-id"
+		for i, sheet := range f.spreadsheet.Sheets {
+			sheet.Properties.SheetId = int64(i + 1)
+		}
+		json.NewEncoder(w).Encode(f.spreadsheet)
+	case r.URL.Path == "/v4/spreadsheets/test-id" && r.Method == http.MethodGet:
+		s := &sheets.Spreadsheet{SpreadsheetId: "test-id"}
+		for i, title := range f.existing {
+			s.Sheets = append(s.Sheets, &sheets.Sheet{Properties: &sheets.SheetProperties{SheetId: int64(100 + i), Title: title}})
+		}
+		json.NewEncoder(w).Encode(s)
+	case r.URL.Path == "/v4/spreadsheets/test-id/values:batchGet":
+		res := &sheets.BatchGetValuesResponse{SpreadsheetId: "test-id"}
+		for _, a1 := range r.URL.Query()["ranges"] {
+			title := strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(a1, "'"), "'!1:1"), "''", "'")
+			vr := &sheets.ValueRange{Range: a1}
+			if h, ok := f.headers[title]; ok {
+				vr.Values = [][]interface{}{h}
+			}
+			res.ValueRanges = append(res.ValueRanges, vr)
+		}
+		json.NewEncoder(w).Encode(res)
+	case r.URL.Path == "/v4/spreadsheets/test-id:batchUpdate":
+		req := &sheets.BatchUpdateSpreadsheetRequest{}
+		json.NewDecoder(r.Body).Decode(req)
+		f.requests = append(f.requests, req.Requests...)
+		res := &sheets.BatchUpdateSpreadsheetResponse{SpreadsheetId: "test-id"}
+		for i, r := range req.Requests {
+			reply := &sheets.Response{}
+			if r.AddSheet != nil {
+				r.AddSheet.Properties.SheetId = int64(200 + i)
+				reply.AddSheet = &sheets.AddSheetResponse{Properties: r.AddSheet.Properties}
+			}
+			res.Replies = append(res.Replies, reply)
+		}
+		json.NewEncoder(w).Encode(res)
+	case r.URL.Path == "/drive/v3/files/test-id/permissions":
+		p := &drive.Permission{}
+		json.NewDecoder(r.Body).Decode(p)
+		f.permissions = append(f.permissions, p)
+		f.notify = append(f.notify, r.URL.Query().Get("sendNotificationEmail"))
+		w.Write([]byte(`{}`))
+	case r.URL.Path == "/drive/v3/files/test-id" && r.Method == http.MethodGet:
+		w.Write([]byte(`{"parents": ["root-id"]}`))
+	case r.URL.Path == "/drive/v3/files/test-id" && r.Method == http.MethodPatch:
+		f.parents = "addParents=" + r.URL.Query().Get("addParents") + "&removeParents=" + r.URL.Query().Get("removeParents")
+		w.Write([]byte(`{}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newFakeWriter returns a writer that uses the fake API.
+func newFakeWriter(t *testing.T, api *fakeAPI) *APIWriter {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	w, err := NewWriter(
+		[]option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithHTTPClient(server.Client())},
+		[]option.ClientOption{option.WithEndpoint(server.URL + "/drive/v3/"), option.WithHTTPClient(server.Client())},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// testReport returns a URL report with a success and a fail.
+func testReport() excel.Report {
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://boratanrikulu.dev/a", "notaavalidurl")
+	urlSet.AddSuccess("https://boratanrikulu.dev/a", []string{"https://boratanrikulu.dev/a-new"})
+	urlSet.AddFail("notaavalidurl", "URL is not valid.")
+	urlSet.Meta.Country, urlSet.Meta.Language = "tr", "tr"
+	urlSet.Meta.Date = time.Date(2020, 12, 1, 15, 4, 0, 0, time.UTC)
+	return excel.URLReport(urlSet, excel.DefaultLayout())
+}
+
+func TestAPIWriter(t *testing.T) {
+	api := &fakeAPI{}
+	w := newFakeWriter(t, api)
+
+	sheetURL, err := w.Write(context.Background(), testReport(), Options{FolderID: "folder-id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheetURL != "https://docs.google.com/spreadsheets/d/test-id" || len(api.permissions) != 1 || api.permissions[0].Type != "anyone" {
+		t.Fatal("Error: Spreadsheet is not created and shared.", sheetURL)
+	}
+	if api.spreadsheet.Properties.Title != "Carbon url tr-tr 2020-12-01 15:04" {
+		t.Fatal("Error: Default name is not valid.", api.spreadsheet.Properties.Title)
+	}
+	if api.parents != "addParents=folder-id&removeParents=root-id" {
+		t.Fatal("Error: Spreadsheet is not moved to the folder.", api.parents)
+	}
+
+	titles := []string{}
+	for _, sheet := range api.spreadsheet.Sheets {
+		titles = append(titles, sheet.Properties.Title)
+	}
+	if strings.Join(titles, ",") != "summary,all,success,fail" {
+		t.Fatal("Error: Tabs must be taken from the layout.", titles)
+	}
+	if api.spreadsheet.Sheets[1].Properties.GridProperties.FrozenRowCount != 1 {
+		t.Fatal("Error: Header of the all sheet must be frozen.")
+	}
+
+	var filter, statusRule, link, chart bool
+	for _, req := range api.requests {
+		switch {
+		case req.SetBasicFilter != nil:
+			filter = req.SetBasicFilter.Filter.Range.SheetId == 2
+		case req.AddConditionalFormatRule != nil:
+			statusRule = true
+		case req.AddChart != nil:
+			chart = req.AddChart.Chart.Spec.PieChart != nil
+		case req.UpdateCells != nil:
+			for _, row := range req.UpdateCells.Rows {
+				for _, cell := range row.Values {
+					if cell.UserEnteredValue != nil && cell.UserEnteredValue.FormulaValue != nil &&
+						*cell.UserEnteredValue.FormulaValue == `=HYPERLINK("https://boratanrikulu.dev/a-new")` {
+						link = true
+					}
+				}
+			}
+		}
+	}
+	if !filter || !statusRule || !link || !chart {
+		t.Fatal("Error: Filters, status colors, links and the chart must be set.", filter, statusRule, link, chart)
+	}
+}
+
+func TestAPIWriterShouldAddTabs(t *testing.T) {
+	api := &fakeAPI{existing: []string{"summary", "all"}}
+	w := newFakeWriter(t, api)
+
+	_, err := w.Write(context.Background(), testReport(), Options{SpreadsheetID: "test-id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.permissions) != 0 {
+		t.Fatal("Error: Sharing of existing spreadsheets must not be changed.")
+	}
+
+	titles := []string{}
+	for _, req := range api.requests {
+		if req.AddSheet != nil {
+			titles = append(titles, req.AddSheet.Properties.Title)
+		}
+	}
+	if strings.Join(titles, ",") != "summary 2020-12-01 15:04,all 2020-12-01 15:04,success,fail" {
+		t.Fatal("Error: Used titles must be suffixed with the date.", titles)
+	}
+}
+
+func TestAPIWriterShouldAppendRows(t *testing.T) {
+	api := &fakeAPI{
+		existing: []string{"summary", "all", "success"},
+		headers: map[string][]interface{}{
+			"all":     {"URL", "Status", "Reason", "Alternative 1", "Alternative 2", "Alternative 3", "Suggested"},
+			"success": {"Notes", "Alternative 1", "URL", "Alternative 2", "Alternative 3", "Suggested"},
+		},
+	}
+	w := newFakeWriter(t, api)
+
+	_, err := w.Write(context.Background(), testReport(), Options{SpreadsheetID: "test-id", Append: AppendRows})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	titles, appends := []string{}, []*sheets.AppendCellsRequest{}
+	for _, req := range api.requests {
+		if req.AddSheet != nil {
+			titles = append(titles, req.AddSheet.Properties.Title)
+		}
+		if req.AppendCells != nil {
+			appends = append(appends, req.AppendCells)
+		}
+	}
+	if strings.Join(titles, ",") != "fail" {
+		t.Fatal("Error: Only missing tabs must be added, without the summary.", titles)
+	}
+	if len(appends) != 2 || appends[0].SheetId != 101 || appends[1].SheetId != 102 {
+		t.Fatal("Error: Rows must be appended to the existing tabs.", appends)
+	}
+
+	// Values must be under the columns that have the same titles.
+	cells := appends[1].Rows[0].Values
+	if len(cells) != 6 || cells[0].UserEnteredValue != nil ||
+		cells[2].UserEnteredValue == nil || *cells[2].UserEnteredValue.FormulaValue != `=HYPERLINK("https://boratanrikulu.dev/a")` ||
+		cells[1].UserEnteredValue == nil || *cells[1].UserEnteredValue.FormulaValue != `=HYPERLINK("https://boratanrikulu.dev/a-new")` {
+		t.Fatal("Error: Values must be mapped to the columns of the tab.", cells)
+	}
+}
+
+func TestAPIWriterShouldRejectAppendingToDifferentColumns(t *testing.T) {
+	api := &fakeAPI{
+		existing: []string{"all"},
+		headers:  map[string][]interface{}{"all": {"URL", "Reason"}},
+	}
+	w := newFakeWriter(t, api)
+
+	_, err := w.Write(context.Background(), testReport(), Options{SpreadsheetID: "test-id", Append: AppendRows})
+	if !errors.Is(err, ErrColumns) {
+		t.Fatal("Error: Appending to a tab without the columns must be rejected.", err)
+	}
+	if len(api.requests) != 0 {
+		t.Fatal("Error: Nothing must be written if the columns don't match.", api.requests)
+	}
+}
+
+func TestAPIWriterShouldFailForUnknownSpreadsheet(t *testing.T) {
+	w := newFakeWriter(t, &fakeAPI{})
+
+	_, err := w.Write(context.Background(), testReport(), Options{SpreadsheetID: "unknown"})
+	if err != ErrSpreadsheetNotFound {
+		t.Fatal("Error: Unknown spreadsheets must not be found.", err)
+	}
+}
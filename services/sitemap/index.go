@@ -0,0 +1,159 @@
+package sitemap
+
+import (
+	neturl "net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/zeoagency/carbon/helpers"
+)
+
+// minScore is the minimum similarity to accept a sitemap URL as an alternative.
+const minScore = 0.2
+
+// Index keeps live URLs of sitemaps to find alternatives by slug similarity.
+//
+// When you use it, firstly create with NewIndex method.
+// Example:
+// i := NewIndex(urls)
+// alternatives := i.Match("https://boratanrikulu.dev/postgresql-nedir/", 3)
+type Index struct {
+	entries []entry
+	tokens  map[string][]int // the key is a token, the value is entry positions.
+}
+
+// entry is an indexed sitemap URL.
+type entry struct {
+	URL     string
+	BaseURL string
+	Tokens  []string
+	Slug    []string // tokens of the last path segment.
+}
+
+// NewIndex indexes the given URLs, invalid URLs are skipped.
+func NewIndex(urls []string) *Index {
+	i := &Index{
+		tokens: make(map[string][]int),
+	}
+
+	for _, u := range urls {
+		baseURL, _, err := helpers.ExtractURL(u)
+		if err != nil {
+			continue
+		}
+		tokens, slug := tokenize(u)
+
+		i.entries = append(i.entries, entry{URL: u, BaseURL: baseURL, Tokens: tokens, Slug: slug})
+		for _, t := range tokens {
+			i.tokens[t] = append(i.tokens[t], len(i.entries)-1)
+		}
+	}
+
+	return i
+}
+
+// Len returns the count of indexed URLs.
+func (i *Index) Len() int {
+	return len(i.entries)
+}
+
+// Match returns the most similar URLs on the same domain, at most limit URLs.
+// The given URL itself is never returned.
+func (i *Index) Match(u string, limit int) []string {
+	baseURL, _, err := helpers.ExtractURL(u)
+	if err != nil {
+		return []string{}
+	}
+	tokens, slug := tokenize(u)
+
+	// Only score entries that share at least one token.
+	candidates := make(map[int]bool)
+	for _, t := range tokens {
+		for _, pos := range i.tokens[t] {
+			candidates[pos] = true
+		}
+	}
+
+	type scored struct {
+		url   string
+		score float64
+	}
+	results := []scored{}
+	for pos := range candidates {
+		e := i.entries[pos]
+		if e.BaseURL != baseURL || helpers.SameURL(e.URL, u) {
+			continue
+		}
+		// The last segment is weighted more, it is the slug of the page mostly.
+		score := 0.4*jaccard(tokens, e.Tokens) + 0.6*jaccard(slug, e.Slug)
+		if score >= minScore {
+			results = append(results, scored{url: e.URL, score: score})
+		}
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].score != results[b].score {
+			return results[a].score > results[b].score
+		}
+		return results[a].url < results[b].url
+	})
+
+	r := []string{}
+	for _, s := range results {
+		if len(r) == limit {
+			break
+		}
+		r = append(r, s.url)
+	}
+	return r
+}
+
+// tokenize returns lowercase words of the URL's path, and words of the last segment.
+func tokenize(u string) ([]string, []string) {
+	_, keywords, err := helpers.ExtractURL(u)
+	if err != nil {
+		return nil, nil
+	}
+
+	last := ""
+	if parsed, err := neturl.Parse(u); err == nil {
+		last = path.Base(strings.TrimSuffix(parsed.Path, "/"))
+		if last == "." || last == "/" {
+			last = ""
+		}
+	}
+
+	return words(keywords), words(helpers.ReplaceAnyWithSpace(last, "-", "_", ".", "html", "php", "aspx"))
+}
+
+// words splits the text to unique lowercase words.
+func words(s string) []string {
+	r := []string{}
+	seen := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		if !seen[w] {
+			seen[w] = true
+			r = append(r, w)
+		}
+	}
+	return r
+}
+
+// jaccard returns the similarity of two word sets.
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool)
+	for _, w := range a {
+		set[w] = true
+	}
+	intersection := 0
+	for _, w := range b {
+		if set[w] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,208 @@
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSitemaps limits the count of sitemaps that are read through sitemap indexes.
+const maxSitemaps = 100
+
+// maxSitemapSize limits the size of a sitemap, it is checked before and after gunzipping.
+// It is the limit of the sitemap protocol, it is lowered in the tests.
+var maxSitemapSize int64 = 50 << 20
+
+// maxURLs limits the count of unique URLs in all sitemaps.
+const maxURLs = 200000
+
+// httpClient fetches sitemaps. The client of the providers is not used,
+// so sitemaps of users don't retry or open the circuits of the providers.
+var httpClient = &http.Client{Timeout: time.Minute}
+
+// errTooLarge is returned by the readers when the sitemap is larger than maxSitemapSize.
+var errTooLarge = fmt.Errorf("The sitemap is larger than %d MB.", maxSitemapSize>>20)
+
+// document keeps both of urlset and sitemapindex documents.
+type document struct {
+	XMLName  xml.Name
+	URLs     []loc `xml:"url"`
+	Sitemaps []loc `xml:"sitemap"`
+}
+
+type loc struct {
+	Loc string `xml:"loc"`
+}
+
+// Opener opens the sitemap at the given location.
+// The location is a "loc" value in a sitemap index.
+type Opener func(location string) (io.ReadCloser, error)
+
+// LoadFile reads the sitemap at the local path.
+// Sitemaps in an index are looked up in the same directory by their file names,
+// so a downloaded sitemap tree can be used without network.
+func LoadFile(p string) ([]string, error) {
+	dir := filepath.Dir(p)
+	open := func(location string) (io.ReadCloser, error) {
+		name := location
+		if u, err := neturl.Parse(location); err == nil && u.Path != "" {
+			name = path.Base(u.Path)
+		}
+		return os.Open(filepath.Join(dir, filepath.Base(name)))
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, open)
+}
+
+// LoadURL downloads the sitemap at the given URL, and the sitemaps in it if it is an index.
+func LoadURL(ctx context.Context, u string) ([]string, error) {
+	open := func(location string) (io.ReadCloser, error) {
+		return fetch(ctx, location)
+	}
+
+	r, err := fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return Load(r, open)
+}
+
+// Load reads the sitemap (or the sitemap index) from the reader, returns unique URLs in it.
+// Gzipped sitemaps are supported. Sitemaps in an index are opened by using the opener one by one,
+// so only one of them is read at a time.
+func Load(r io.Reader, open Opener) ([]string, error) {
+	urls := []string{}
+	seen := make(map[string]bool)
+	locations := []string{}
+
+	read := func(r io.Reader) error {
+		doc, err := decode(r)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range doc.URLs {
+			u.Loc = strings.TrimSpace(u.Loc)
+			if u.Loc != "" && !seen[u.Loc] {
+				if len(urls) >= maxURLs {
+					return fmt.Errorf("There are more than %d URLs in the sitemaps.", maxURLs)
+				}
+				seen[u.Loc] = true
+				urls = append(urls, u.Loc)
+			}
+		}
+
+		for _, s := range doc.Sitemaps {
+			if len(locations) >= maxSitemaps {
+				return fmt.Errorf("There are more than %d sitemaps in the index.", maxSitemaps)
+			}
+			locations = append(locations, strings.TrimSpace(s.Loc))
+		}
+		return nil
+	}
+
+	err := read(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(locations); i++ {
+		rc, err := open(locations[i])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open the sitemap \"%s\".", locations[i])
+		}
+		err = read(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return urls, nil
+}
+
+// decode decodes the sitemap document, it is gunzipped if it is needed.
+// The sitemap and its gunzipped stream are limited by maxSitemapSize.
+func decode(r io.Reader) (document, error) {
+	br := bufio.NewReader(&limitedReader{r: r, n: maxSitemapSize})
+	var reader io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return document{}, errors.New("Unable to read the gzipped sitemap.")
+		}
+		defer gz.Close()
+		reader = &limitedReader{r: gz, n: maxSitemapSize}
+	}
+
+	doc := document{}
+	err := xml.NewDecoder(reader).Decode(&doc)
+	if errors.Is(err, errTooLarge) {
+		return document{}, errTooLarge
+	}
+	if err != nil {
+		return document{}, errors.New("Unable to parse the sitemap. Check your file.")
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return document{}, errors.New("That's not a sitemap.")
+	}
+
+	return doc, nil
+}
+
+// limitedReader works like io.LimitReader, but it returns errTooLarge instead of EOF when the limit is exceeded,
+// so a cut sitemap is not taken as a whole one.
+type limitedReader struct {
+	r io.Reader
+	n int64 // remaining bytes.
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errTooLarge
+	}
+	return n, err
+}
+
+// fetch downloads the given URL.
+func fetch(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !(res.StatusCode >= 200 && res.StatusCode <= 299) {
+		res.Body.Close()
+		return nil, fmt.Errorf("Unable to download the sitemap. Status: %d", res.StatusCode)
+	}
+	return res.Body, nil
+}
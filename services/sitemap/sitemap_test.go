@@ -0,0 +1,68 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	urls, err := LoadFile("testdata/sitemap_index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contact page is in both sitemaps.
+	if len(urls) != 6 {
+		t.Fatal("Error: Sitemap index issue.", urls)
+	}
+}
+
+func TestLoadShouldLimitGunzippedSize(t *testing.T) {
+	defer func(size int64) { maxSitemapSize = size }(maxSitemapSize)
+	maxSitemapSize = 4 << 10
+
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	gz.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>https://zeo.org/</loc></url>`))
+	gz.Write(bytes.Repeat([]byte(" "), int(maxSitemapSize)))
+	gz.Write([]byte(`</urlset>`))
+	gz.Close()
+
+	_, err := Load(b, nil)
+	if err != errTooLarge {
+		t.Fatal("Error: Gunzipped size is not limited.", err)
+	}
+
+	urls, err := Load(strings.NewReader(`<urlset><url><loc>https://zeo.org/</loc></url></urlset>`), nil)
+	if err != nil || len(urls) != 1 {
+		t.Fatal("Error: Small sitemap is not read.", urls, err)
+	}
+}
+
+func TestIndexMatch(t *testing.T) {
+	urls, err := LoadFile("testdata/sitemap_index.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := NewIndex(urls)
+
+	r := i.Match("https://boratanrikulu.dev/postgresql-nedir", 3)
+	if !reflect.DeepEqual(r, []string{"https://boratanrikulu.dev/postgresql-nedir-nasil-calisir/"}) {
+		t.Fatal("Error: Match issue.", r)
+	}
+
+	// The URL itself is not an alternative.
+	r = i.Match("https://boratanrikulu.dev/contact", 3)
+	if len(r) != 0 {
+		t.Fatal("Error: The URL itself is matched.", r)
+	}
+
+	// Other domains are not matched.
+	r = i.Match("https://zeo.org/postgresql-nedir", 3)
+	if len(r) != 0 {
+		t.Fatal("Error: Another domain is matched.", r)
+	}
+}
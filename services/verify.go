@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+
+	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/check"
+)
+
+// VerifyAlternatives checks whether alternatives of the successes are live.
+// Alternatives that are not 200 or are noindex are dropped if drop is true,
+// otherwise they are moved after the live ones.
+// If no alternative is live, the URL is moved to the fail list in both modes.
+// Alternatives of a URL are kept as they are if they are not checked before the context is done.
+func VerifyAlternatives(ctx context.Context, urls *models.URLSet, checker *check.Checker, drop bool) {
+	all := []string{}
+	for _, success := range urls.Successes {
+		all = append(all, success.URLs...)
+	}
+	checks := checker.CheckAll(ctx, all)
+
+	for originalURL, success := range urls.Successes {
+		if !isChecked(checks, success.URLs) {
+			continue
+		}
+
+		live, notLive := []string{}, []string{}
+		own := make(map[string]models.URLCheck)
+		for _, u := range success.URLs {
+			own[u] = checks[u]
+			if checks[u].IsLive() {
+				live = append(live, u)
+			} else {
+				notLive = append(notLive, u)
+			}
+		}
+
+		if !drop {
+			live = append(live, notLive...)
+		}
+		urls.SetChecks(originalURL, live, own, "Related URLs are not live.")
+	}
+}
+
+// PreCheckURLs checks whether the input URLs are really broken before looking up alternatives.
+// Each URL is classified, healthy URLs (live pages and redirects to live pages) are skipped.
+// URLs that are not checked before the context is done are looked up without a class.
+func PreCheckURLs(ctx context.Context, urls *models.URLSet, checker *check.Checker) {
+	all := []string{}
+	for _, url := range urls.URLs {
+		all = append(all, url.FullURL)
+	}
+	checks := checker.CheckAll(ctx, all)
+
+	for _, originalURL := range all {
+		if !isChecked(checks, []string{originalURL}) {
+			continue
+		}
+
+		class, healthy := check.Classify(originalURL, checks[originalURL])
+		urls.SetClass(originalURL, class)
+		if healthy {
+			urls.AddSkip(originalURL, "The URL is not broken.")
+		}
+	}
+}
+
+// isChecked tells whether all URLs have a check.
+func isChecked(checks map[string]models.URLCheck, urls []string) bool {
+	for _, u := range urls {
+		if _, ok := checks[u]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zeoagency/carbon/models"
+	"github.com/zeoagency/carbon/services/check"
+)
+
+func TestVerifyAlternatives(t *testing.T) {
+	os.Setenv("CHECK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CHECK_ALLOW_PRIVATE")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" || r.URL.Path == "/dead-too" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	for _, drop := range []bool{true, false} {
+		urlSet := models.NewURLSet()
+		urlSet.AddSuccess("https://zeo.org/a", []string{ts.URL + "/dead", ts.URL + "/live"})
+		urlSet.AddSuccess("https://zeo.org/b", []string{ts.URL + "/dead-too"})
+
+		VerifyAlternatives(context.Background(), urlSet, check.NewChecker(), drop)
+
+		success := urlSet.Successes["https://zeo.org/a"]
+		if success.URLs[0] != ts.URL+"/live" || success.SuggestedURL != ts.URL+"/live" {
+			t.Fatal("Error: Live alternative is not preferred.", success)
+		}
+		if drop && len(success.URLs) != 1 {
+			t.Fatal("Error: Dead alternative is not dropped.", success)
+		}
+		if !drop && len(success.URLs) != 2 {
+			t.Fatal("Error: Dead alternative is not demoted.", success)
+		}
+
+		_, failed := urlSet.Fails["https://zeo.org/b"]
+		if !failed {
+			t.Fatal("Error: URL without live alternatives issue.")
+		}
+	}
+}
+
+func TestVerifyAlternativesShouldNotSuggestDeadPagesWhenDemoting(t *testing.T) {
+	os.Setenv("CHECK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CHECK_ALLOW_PRIVATE")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	urlSet := models.NewURLSet()
+	urlSet.AddSuccess("https://zeo.org/a", []string{ts.URL + "/dead", ts.URL + "/dead-too", ts.URL + "/also-dead"})
+
+	VerifyAlternatives(context.Background(), urlSet, check.NewChecker(), false)
+
+	if success, ok := urlSet.Successes["https://zeo.org/a"]; ok {
+		t.Fatal("Error: Dead page is suggested.", success)
+	}
+	if urlSet.Fails["https://zeo.org/a"].Reason != "Related URLs are not live." {
+		t.Fatal("Error: URL is not moved to the fail list.", urlSet.Fails)
+	}
+}
+
+func TestPreCheckURLs(t *testing.T) {
+	os.Setenv("CHECK_ALLOW_PRIVATE", "true")
+	defer os.Unsetenv("CHECK_ALLOW_PRIVATE")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	// The test server is on an IP, so the URLs are added directly.
+	urlSet := models.NewURLSet()
+	urlSet.Add("https://boratanrikulu.dev/dead", "https://boratanrikulu.dev/live")
+	for k, u := range urlSet.URLs {
+		u.FullURL = strings.Replace(u.FullURL, "https://boratanrikulu.dev", ts.URL, 1)
+		urlSet.URLs[k] = u
+	}
+
+	PreCheckURLs(context.Background(), urlSet, check.NewChecker())
+
+	if len(urlSet.URLs) != 1 || len(urlSet.Skips) != 1 {
+		t.Fatal("Error: Live URL is not skipped.", urlSet.Skips)
+	}
+	if urlSet.Classes[ts.URL+"/dead"] != "Not Found (404)" {
+		t.Fatal("Error: Class issue.", urlSet.Classes)
+	}
+}
+
+func TestVerifyAlternativesShouldKeepUncheckedOnes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	urlSet := models.NewURLSet()
+	urlSet.AddSuccess("https://zeo.org/a", []string{"https://zeo.org/dead", "https://zeo.org/live"})
+
+	VerifyAlternatives(ctx, urlSet, check.NewChecker(), true)
+
+	success, ok := urlSet.Successes["https://zeo.org/a"]
+	if !ok || len(success.URLs) != 2 || success.Checks != nil {
+		t.Fatal("Error: Unchecked alternatives are changed.", success)
+	}
+}
+
+func TestVerifyAlternativesShouldNotFollowPrivateAddresses(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer ts.Close()
+
+	urlSet := models.NewURLSet()
+	urlSet.AddSuccess("https://zeo.org/a", []string{ts.URL + "/live", "http://169.254.169.254/latest/meta-data"})
+
+	VerifyAlternatives(context.Background(), urlSet, check.NewChecker(), true)
+
+	if hits != 0 {
+		t.Fatal("Error: Private address is dialed.", hits)
+	}
+	if _, failed := urlSet.Fails["https://zeo.org/a"]; !failed {
+		t.Fatal("Error: Alternatives on private addresses must not be live.", urlSet.Successes)
+	}
+}